@@ -9,7 +9,10 @@ package cli
 import (
 	"fmt"
 	"os"
+	"os/exec"
 	"runtime"
+	"strconv"
+	"strings"
 
 	"github.com/essentialkaos/ek/v13/env"
 	"github.com/essentialkaos/ek/v13/errors"
@@ -37,6 +40,7 @@ import (
 
 	"github.com/funbox/init-exporter/export"
 	"github.com/funbox/init-exporter/procfile"
+	"github.com/funbox/init-exporter/report"
 )
 
 // ////////////////////////////////////////////////////////////////////////////////// //
@@ -51,11 +55,16 @@ const (
 // Supported arguments
 const (
 	OPT_PROCFILE           = "p:procfile"
+	OPT_PROFILE            = "P:profile"
 	OPT_APP_NAME           = "n:appname"
 	OPT_DRY_START          = "d:dry-start"
 	OPT_DISABLE_VALIDATION = "D:disable-validation"
+	OPT_STRICT             = "S:strict"
 	OPT_UNINSTALL          = "u:uninstall"
 	OPT_FORMAT             = "f:format"
+	OPT_OUTPUT             = "o:output"
+	OPT_CHECK              = "C:check"
+	OPT_RUN_TASKS          = "R:run-tasks"
 	OPT_NO_COLOR           = "nc:no-color"
 	OPT_HELP               = "h:help"
 	OPT_VER                = "v:version"
@@ -73,11 +82,14 @@ const (
 
 	PROCFILE_VERSION1 = "procfile:version1"
 	PROCFILE_VERSION2 = "procfile:version2"
+	PROCFILE_ENV_VARS = "procfile:env-vars"
 
-	PATHS_WORKING_DIR = "paths:working-dir"
-	PATHS_HELPER_DIR  = "paths:helper-dir"
-	PATHS_SYSTEMD_DIR = "paths:systemd-dir"
-	PATHS_UPSTART_DIR = "paths:upstart-dir"
+	PATHS_WORKING_DIR   = "paths:working-dir"
+	PATHS_HELPER_DIR    = "paths:helper-dir"
+	PATHS_SYSTEMD_DIR   = "paths:systemd-dir"
+	PATHS_UPSTART_DIR   = "paths:upstart-dir"
+	PATHS_CRON_DIR      = "paths:cron-dir"
+	PATHS_LOGROTATE_DIR = "paths:logrotate-dir"
 
 	DEFAULTS_NPROC            = "defaults:nproc"
 	DEFAULTS_NOFILE           = "defaults:nofile"
@@ -85,6 +97,15 @@ const (
 	DEFAULTS_RESPAWN_COUNT    = "defaults:respawn-count"
 	DEFAULTS_RESPAWN_INTERVAL = "defaults:respawn-interval"
 	DEFAULTS_KILL_TIMEOUT     = "defaults:kill-timeout"
+	DEFAULTS_SECURITY_PRESET  = "defaults:security-preset"
+
+	LOGROTATE_ENABLED  = "logrotate:enabled"
+	LOGROTATE_PERIOD   = "logrotate:period"
+	LOGROTATE_ROTATE   = "logrotate:rotate"
+	LOGROTATE_MAX_SIZE = "logrotate:max-size"
+	LOGROTATE_COMPRESS = "logrotate:compress"
+
+	LINT_SECTION = "lint"
 
 	LOG_ENABLED = "log:enabled"
 	LOG_DIR     = "log:dir"
@@ -100,6 +121,26 @@ const (
 	FORMAT_SYSTEMD = "systemd"
 )
 
+const (
+	// OUTPUT_TEXT contains name for human-readable validation output
+	OUTPUT_TEXT = "text"
+	// OUTPUT_JSON contains name for JSON validation output
+	OUTPUT_JSON = "json"
+	// OUTPUT_SARIF contains name for SARIF validation output
+	OUTPUT_SARIF = "sarif"
+)
+
+const (
+	// CMD_LINT contains name of command for checking procfile for risky configuration
+	CMD_LINT = "lint"
+	// CMD_CONVERT contains name of command for converting procfile v1 to v2
+	CMD_CONVERT = "convert"
+	// CMD_FMT contains name of command for formatting procfile
+	CMD_FMT = "fmt"
+	// CMD_HEALTH contains name of command for running services health checks
+	CMD_HEALTH = "health"
+)
+
 // CONFIG_FILE contains path to config file
 const CONFIG_FILE = "/etc/init-exporter.conf"
 
@@ -108,10 +149,15 @@ const CONFIG_FILE = "/etc/init-exporter.conf"
 var optMap = options.Map{
 	OPT_APP_NAME:           {},
 	OPT_PROCFILE:           {},
+	OPT_PROFILE:            {},
 	OPT_DRY_START:          {Type: options.BOOL},
 	OPT_DISABLE_VALIDATION: {Type: options.BOOL},
+	OPT_STRICT:             {Type: options.BOOL},
 	OPT_UNINSTALL:          {Type: options.BOOL, Alias: "c:clear"},
 	OPT_FORMAT:             {},
+	OPT_OUTPUT:             {Value: OUTPUT_TEXT},
+	OPT_CHECK:              {Type: options.BOOL},
+	OPT_RUN_TASKS:          {Type: options.BOOL},
 	OPT_NO_COLOR:           {Type: options.BOOL},
 	OPT_HELP:               {Type: options.BOOL},
 	OPT_VER:                {Type: options.BOOL},
@@ -165,6 +211,28 @@ func Run(gitRev string, gomod []byte) {
 		os.Exit(0)
 	}
 
+	cmd := args.Get(0).String()
+
+	if isCommand(cmd) && (options.GetB(OPT_UNINSTALL) ||
+		options.GetB(OPT_DRY_START) || options.GetB(OPT_RUN_TASKS)) {
+		printErrorAndExit("Command %s can't be used with export options", cmd)
+	}
+
+	switch cmd {
+	case CMD_LINT:
+		lintProcfile()
+		return
+	case CMD_CONVERT:
+		convertProcfile(args.Get(1).String())
+		return
+	case CMD_FMT:
+		formatProcfile()
+		return
+	case CMD_HEALTH:
+		checkHealth()
+		return
+	}
+
 	err := errors.Chain(
 		checkForRoot,
 		checkOptions,
@@ -177,12 +245,17 @@ func Run(gitRev string, gomod []byte) {
 		printErrorAndExit(err.Error())
 	}
 
-	switch {
-	case len(args) == 0:
-		startProcessing(options.GetS(OPT_APP_NAME))
-	default:
-		startProcessing(args.Get(0).String())
+	appName := options.GetS(OPT_APP_NAME)
+
+	if len(args) != 0 {
+		appName = args.Get(0).String()
+	}
+
+	if isCommand(appName) {
+		printErrorAndExit("Name %q is reserved for command and can't be used as application name", appName)
 	}
+
+	startProcessing(appName)
 }
 
 // preConfigureUI preconfigures UI based on information about user terminal
@@ -208,6 +281,16 @@ func configureUI() {
 	}
 }
 
+// isCommand returns true if given name is name of command
+func isCommand(name string) bool {
+	switch name {
+	case CMD_LINT, CMD_CONVERT, CMD_FMT, CMD_HEALTH:
+		return true
+	}
+
+	return false
+}
+
 // checkForRoot checks superuser privileges
 func checkForRoot() error {
 	var err error
@@ -227,6 +310,13 @@ func checkForRoot() error {
 
 // checkOptions checks given arguments
 func checkOptions() error {
+	switch options.GetS(OPT_OUTPUT) {
+	case OUTPUT_TEXT, OUTPUT_JSON, OUTPUT_SARIF:
+		// ok
+	default:
+		return fmt.Errorf("Unsupported output format %q", options.GetS(OPT_OUTPUT))
+	}
+
 	if !options.GetB(OPT_UNINSTALL) {
 		proc := options.GetS(OPT_PROCFILE)
 		err := fsutil.ValidatePerms("FRS", proc)
@@ -250,6 +340,28 @@ func loadConfig() error {
 	return nil
 }
 
+// loadLintConfig loads configuration file if it exists and validates
+// lint section
+func loadLintConfig() error {
+	if !fsutil.IsExist(CONFIG_FILE) {
+		return nil
+	}
+
+	err := loadConfig()
+
+	if err != nil {
+		return err
+	}
+
+	errs := knf.Validate(getLintValidators())
+
+	if !errs.IsEmpty() {
+		return errs.First()
+	}
+
+	return nil
+}
+
 // validateConfig validates configuration file values
 func validateConfig() error {
 	validators := knf.Validators{
@@ -271,6 +383,12 @@ func validateConfig() error {
 		{DEFAULTS_RESPAWN_INTERVAL, knfv.Greater, 0},
 		{DEFAULTS_KILL_TIMEOUT, knfv.Greater, 0},
 
+		{DEFAULTS_SECURITY_PRESET, knfv.SetToAny, []string{
+			"", procfile.SECURITY_PRESET_NONE, procfile.SECURITY_PRESET_DEFAULT, procfile.SECURITY_PRESET_STRICT,
+		}},
+
+		{LOGROTATE_PERIOD, knfv.SetToAny, []string{"", "daily", "weekly", "monthly", "yearly"}},
+
 		{MAIN_RUN_USER, knfs.User, nil},
 		{MAIN_RUN_GROUP, knfs.Group, nil},
 
@@ -278,6 +396,8 @@ func validateConfig() error {
 		{PATHS_HELPER_DIR, knff.Perms, "DRWX"},
 	}
 
+	validators = validators.Add(getLintValidators())
+
 	validators.AddIf(knf.GetB(LOG_ENABLED, true), knf.Validators{
 		{LOG_DIR, knfv.Set, nil},
 		{LOG_FILE, knfv.Set, nil},
@@ -324,24 +444,10 @@ func startProcessing(appName string) {
 func installApplication(appName string) {
 	fullAppName := knf.GetS(MAIN_PREFIX) + appName
 
-	app, err := procfile.Read(
-		options.GetS(OPT_PROCFILE),
-		&procfile.Config{
-			Name:             fullAppName,
-			User:             knf.GetS(MAIN_RUN_USER),
-			Group:            knf.GetS(MAIN_RUN_GROUP),
-			WorkingDir:       knf.GetS(PATHS_WORKING_DIR),
-			IsRespawnEnabled: knf.GetB(DEFAULTS_RESPAWN, false),
-			RespawnInterval:  knf.GetI(DEFAULTS_RESPAWN_INTERVAL),
-			RespawnCount:     knf.GetI(DEFAULTS_RESPAWN_COUNT),
-			KillTimeout:      knf.GetI(DEFAULTS_KILL_TIMEOUT, 0),
-			LimitFile:        knf.GetI(DEFAULTS_NOFILE, 0),
-			LimitProc:        knf.GetI(DEFAULTS_NPROC, 0),
-		},
-	)
+	app, err := procfile.Read(options.GetS(OPT_PROCFILE), getProcfileConfig(fullAppName))
 
 	if err != nil {
-		printErrorAndExit(err.Error())
+		printValidationErrorsAndExit([]error{err})
 	}
 
 	validateApplication(app)
@@ -350,14 +456,212 @@ func installApplication(appName string) {
 		os.Exit(0)
 	}
 
-	err = getExporter().Install(app)
+	exporter := getExporter()
+	err = exporter.Install(app)
 
-	if err == nil {
-		log.Info("User %s (%d) installed service %s", user.RealName, user.RealUID, app.Name)
-	} else {
+	if err != nil {
 		log.Error(err.Error())
 		printErrorAndExit(err.Error())
 	}
+
+	log.Info("User %s (%d) installed service %s", user.RealName, user.RealUID, app.Name)
+
+	if options.GetB(OPT_RUN_TASKS) && app.HasTasks() {
+		log.Info("User %s (%d) executed tasks of service %s", user.RealName, user.RealUID, app.Name)
+	}
+}
+
+// lintProcfile checks procfile for errors and risky configuration
+func lintProcfile() {
+	err := errors.Chain(
+		checkOptions,
+		loadLintConfig,
+	)
+
+	if err != nil {
+		printErrorAndExit(err.Error())
+	}
+
+	log.Set(os.DevNull, 0)
+
+	app, err := procfile.Read(options.GetS(OPT_PROCFILE), getProcfileConfig(""))
+
+	if err != nil {
+		printValidationErrorsAndExit([]error{err})
+	}
+
+	errs := app.Validate()
+
+	if len(errs) != 0 {
+		printValidationErrorsAndExit(errs)
+	}
+
+	// Lint works without detected init system, in this case
+	// provider-specific rules are skipped
+	providerName, _ := detectProvider(options.GetS(OPT_FORMAT))
+	lintConfig := &procfile.LintConfig{
+		Format:   providerName,
+		Severity: make(map[string]string),
+	}
+
+	for _, rule := range procfile.LintRules {
+		lintConfig.Severity[rule] = knf.GetS(LINT_SECTION + ":" + rule)
+	}
+
+	warnings := app.Lint(lintConfig)
+
+	printLintReport(warnings)
+
+	for _, w := range warnings {
+		if w.Severity == procfile.SEVERITY_ERROR {
+			os.Exit(1)
+		}
+	}
+}
+
+// convertProcfile converts procfile v1 to v2 and prints result or saves it
+// to given file
+func convertProcfile(output string) {
+	log.Set(os.DevNull, 0)
+
+	data, warnings, err := procfile.ConvertV1(options.GetS(OPT_PROCFILE))
+
+	if err != nil {
+		printErrorAndExit(err.Error())
+	}
+
+	for _, w := range warnings {
+		terminal.Warn("%v", w)
+	}
+
+	if output == "" {
+		fmt.Print(string(data))
+		return
+	}
+
+	err = os.WriteFile(output, data, 0644)
+
+	if err != nil {
+		printErrorAndExit("Can't save converted procfile to %s: %v", output, err)
+	}
+}
+
+// formatProcfile rewrites procfile with canonical order of properties and
+// indentation or checks that procfile is already formatted
+func formatProcfile() {
+	proc := options.GetS(OPT_PROCFILE)
+	err := fsutil.ValidatePerms("FRS", proc)
+
+	if err != nil {
+		printErrorAndExit("Can't use procfile %q: %v", proc, err)
+	}
+
+	data, err := os.ReadFile(proc)
+
+	if err != nil {
+		printErrorAndExit("Can't read procfile %q: %v", proc, err)
+	}
+
+	formatted, err := procfile.Format(data)
+
+	if err != nil {
+		printErrorAndExit("Can't format procfile %q: %v", proc, err)
+	}
+
+	if options.GetB(OPT_CHECK) {
+		if string(data) != string(formatted) {
+			printErrorAndExit("Procfile %q is not formatted", proc)
+		}
+
+		return
+	}
+
+	if string(data) == string(formatted) {
+		return
+	}
+
+	err = os.WriteFile(proc, formatted, fsutil.GetMode(proc))
+
+	if err != nil {
+		printErrorAndExit("Can't save procfile %q: %v", proc, err)
+	}
+}
+
+// checkHealth runs health checks of all services once and prints result
+func checkHealth() {
+	err := errors.Chain(
+		checkOptions,
+		loadLintConfig,
+	)
+
+	if err != nil {
+		printErrorAndExit(err.Error())
+	}
+
+	log.Set(os.DevNull, 0)
+
+	app, err := procfile.Read(options.GetS(OPT_PROCFILE), getProcfileConfig(""))
+
+	if err != nil {
+		printValidationErrorsAndExit([]error{err})
+	}
+
+	errs := app.Validate()
+
+	if len(errs) != 0 {
+		printValidationErrorsAndExit(errs)
+	}
+
+	var checked, failed int
+
+	for _, service := range app.Services {
+		if !service.HasHealthCheck() {
+			continue
+		}
+
+		if service.Options.Count <= 0 {
+			failed += runHealthCheck(service, "")
+			checked++
+			continue
+		}
+
+		for i := 1; i <= service.Options.Count; i++ {
+			failed += runHealthCheck(service, strconv.Itoa(i))
+			checked++
+		}
+	}
+
+	if checked == 0 {
+		terminal.Warn("Procfile doesn't contain services with health checks")
+		return
+	}
+
+	if failed != 0 {
+		os.Exit(1)
+	}
+}
+
+// runHealthCheck runs health check of service instance and returns 1 if
+// check failed
+func runHealthCheck(service *procfile.Service, index string) int {
+	service = service.WithInstance(index)
+	name := service.Name + index
+
+	output, err := exec.Command("/bin/bash", "-c", export.GetHealthCheckCommand(service)).CombinedOutput()
+
+	if err != nil {
+		fmtc.Printfn("{r}✖ {!}%s {s}(%v){!}", name, err)
+
+		if len(output) != 0 {
+			fmtc.Printfn("{s-}%s{!}", strings.TrimSpace(string(output)))
+		}
+
+		return 1
+	}
+
+	fmtc.Printfn("{g}✔ {!}%s", name)
+
+	return 0
 }
 
 // uninstallApplication uninstalls application from init system
@@ -377,31 +681,196 @@ func uninstallApplication(appName string) {
 
 // validateApplication validates application and all services
 func validateApplication(app *procfile.Application) {
+	var errs []error
+
 	if app.ProcVersion == 1 && !knf.GetB(PROCFILE_VERSION1, true) {
-		printErrorAndExit("Procfile format version 1 support is disabled")
+		errs = append(errs, &procfile.Error{
+			Rule:    procfile.RULE_UNSUPPORTED,
+			Message: "Procfile format version 1 support is disabled",
+		})
 	}
 
 	if app.ProcVersion == 2 && !knf.GetB(PROCFILE_VERSION2, true) {
-		printErrorAndExit("Procfile format version 2 support is disabled")
+		errs = append(errs, &procfile.Error{
+			Rule:    procfile.RULE_UNSUPPORTED,
+			Message: "Procfile format version 2 support is disabled",
+		})
+	}
+
+	if len(errs) == 0 && (options.GetB(OPT_DRY_START) || !options.GetB(OPT_DISABLE_VALIDATION)) {
+		errs = app.Validate()
+	}
+
+	// Env files, users and groups are checked only on install, because they
+	// can be missing on the host where procfile is validated
+	if len(errs) == 0 && !options.GetB(OPT_DRY_START) && !options.GetB(OPT_DISABLE_VALIDATION) {
+		errs = append(app.ValidateEnvFiles(), app.ValidateUsers()...)
+	}
+
+	// All profiles are validated on dry start, so broken profile is detected
+	// before it is used
+	if len(errs) == 0 && options.GetB(OPT_DRY_START) {
+		errs = validateProfiles(app)
+	}
+
+	if len(errs) != 0 {
+		printValidationErrorsAndExit(errs)
 	}
 
-	if !options.GetB(OPT_DRY_START) && options.GetB(OPT_DISABLE_VALIDATION) {
+	if options.GetS(OPT_OUTPUT) != OUTPUT_TEXT {
+		printValidationReport(nil)
+	}
+}
+
+// validateProfiles validates application with every profile defined in procfile
+func validateProfiles(app *procfile.Application) []error {
+	var errs []error
+
+	for _, profile := range app.Profiles {
+		if profile == app.Profile {
+			continue
+		}
+
+		config := getProcfileConfig(app.Name)
+		config.Profile = profile
+
+		profileApp, err := procfile.Read(options.GetS(OPT_PROCFILE), config)
+
+		if err != nil {
+			errs = append(errs, addProfileInfo(err, profile))
+			continue
+		}
+
+		for _, err := range profileApp.Validate() {
+			errs = append(errs, addProfileInfo(err, profile))
+		}
+	}
+
+	return errs
+}
+
+// addProfileInfo adds name of profile to error message
+func addProfileInfo(err error, profile string) error {
+	e, ok := err.(*procfile.Error)
+
+	if !ok {
+		return fmt.Errorf("%v (profile %s)", err, profile)
+	}
+
+	e.Message += fmt.Sprintf(" (profile %s)", profile)
+
+	return e
+}
+
+// printValidationErrorsAndExit prints validation errors in requested format
+// and exit with exit code 1
+func printValidationErrorsAndExit(errs []error) {
+	printValidationReport(errs)
+	os.Exit(1)
+}
+
+// printValidationReport prints validation errors in requested format
+func printValidationReport(errs []error) {
+	if options.GetS(OPT_OUTPUT) == OUTPUT_TEXT {
+		if len(errs) == 0 {
+			return
+		}
+
+		terminal.Error("Errors while application validation:")
+
+		for _, err := range errs {
+			terminal.Error(" - %v", err)
+		}
+
 		return
 	}
 
-	errs := app.Validate()
+	printReport(report.FromErrors(errs))
+}
 
-	if len(errs) == 0 {
+// printLintReport prints lint warnings in requested format
+func printLintReport(warnings []*procfile.Warning) {
+	if options.GetS(OPT_OUTPUT) != OUTPUT_TEXT {
+		printReport(report.FromWarnings(warnings))
 		return
 	}
 
-	terminal.Error("Errors while application validation:")
+	for _, w := range warnings {
+		if w.Severity == procfile.SEVERITY_ERROR {
+			terminal.Error("%v [%s]", w, w.Rule)
+		} else {
+			terminal.Warn("%v [%s]", w, w.Rule)
+		}
+	}
+}
+
+// printReport prints findings in JSON or SARIF format
+func printReport(findings []*report.Finding) {
+	var data []byte
+	var err error
 
-	for _, err := range errs {
-		terminal.Error(" - %v", err)
+	switch options.GetS(OPT_OUTPUT) {
+	case OUTPUT_JSON:
+		data, err = report.JSON(findings)
+	case OUTPUT_SARIF:
+		data, err = report.SARIF(findings, report.Tool{
+			Name: APP, Version: VER, URL: "https://github.com/funbox/init-exporter",
+		})
 	}
 
-	os.Exit(1)
+	if err != nil {
+		printErrorAndExit("Can't encode validation report: %v", err)
+	}
+
+	fmt.Println(string(data))
+}
+
+// getProcfileConfig returns configuration for procfile parser
+func getProcfileConfig(appName string) *procfile.Config {
+	return &procfile.Config{
+		Name:             appName,
+		User:             knf.GetS(MAIN_RUN_USER),
+		Group:            knf.GetS(MAIN_RUN_GROUP),
+		WorkingDir:       knf.GetS(PATHS_WORKING_DIR),
+		IsRespawnEnabled: knf.GetB(DEFAULTS_RESPAWN, false),
+		RespawnInterval:  knf.GetI(DEFAULTS_RESPAWN_INTERVAL),
+		RespawnCount:     knf.GetI(DEFAULTS_RESPAWN_COUNT),
+		KillTimeout:      knf.GetI(DEFAULTS_KILL_TIMEOUT, 0),
+		LimitFile:        knf.GetI(DEFAULTS_NOFILE, 0),
+		LimitProc:        knf.GetI(DEFAULTS_NPROC, 0),
+		IsStrict:         options.GetB(OPT_STRICT) || options.GetB(OPT_DRY_START),
+		UseEnvVars:       knf.GetB(PROCFILE_ENV_VARS, false),
+		Profile:          options.GetS(OPT_PROFILE),
+		SecurityPreset:   knf.GetS(DEFAULTS_SECURITY_PRESET),
+		Logrotate:        getLogrotateConfig(),
+	}
+}
+
+// getLogrotateConfig returns default rotation policy for logs
+func getLogrotateConfig() *procfile.Logrotate {
+	if !knf.GetB(LOGROTATE_ENABLED, false) {
+		return nil
+	}
+
+	return &procfile.Logrotate{
+		Period:   knf.GetS(LOGROTATE_PERIOD, "weekly"),
+		Rotate:   knf.GetI(LOGROTATE_ROTATE, 4),
+		MaxSize:  knf.GetS(LOGROTATE_MAX_SIZE),
+		Compress: knf.GetB(LOGROTATE_COMPRESS, true),
+	}
+}
+
+// getLintValidators returns validators for lint section in configuration file
+func getLintValidators() knf.Validators {
+	var validators knf.Validators
+
+	severities := []string{"", procfile.SEVERITY_ERROR, procfile.SEVERITY_WARNING, procfile.SEVERITY_OFF}
+
+	for _, rule := range procfile.LintRules {
+		validators = append(validators, &knf.Validator{LINT_SECTION + ":" + rule, knfv.SetToAny, severities})
+	}
+
+	return validators
 }
 
 // checkProviderTargetDir check permissions on target dir
@@ -423,11 +892,16 @@ func getExporter() *export.Exporter {
 
 	var provider export.Provider
 
-	exportConfig := &export.Config{HelperDir: knf.GetS(PATHS_HELPER_DIR)}
+	exportConfig := &export.Config{
+		HelperDir:    knf.GetS(PATHS_HELPER_DIR),
+		LogrotateDir: knf.GetS(PATHS_LOGROTATE_DIR),
+		RunTasks:     options.GetB(OPT_RUN_TASKS),
+	}
 
 	switch providerName {
 	case FORMAT_UPSTART:
 		exportConfig.TargetDir = knf.GetS(PATHS_UPSTART_DIR)
+		exportConfig.CronDir = knf.GetS(PATHS_CRON_DIR, "/etc/cron.d")
 		provider = export.NewUpstart()
 	case FORMAT_SYSTEMD:
 		exportConfig.TargetDir = knf.GetS(PATHS_SYSTEMD_DIR)
@@ -500,21 +974,39 @@ func genUsage() *usage.Info {
 
 	info.AppNameColorTag = "{*}" + colorTagApp
 
+	info.AddCommand(CMD_LINT, "Check procfile for risky configuration")
+	info.AddCommand(CMD_CONVERT, "Convert procfile v1 to v2 format", "?output")
+	info.AddCommand(CMD_FMT, "Rewrite procfile with canonical order of properties and indentation")
+	info.AddCommand(CMD_HEALTH, "Run health checks of services once")
+
 	info.AddOption(OPT_PROCFILE, "Path to procfile", "file")
+	info.AddOption(OPT_PROFILE, "Name of procfile profile", "name")
 	info.AddOption(OPT_DRY_START, "Dry start {s-}(don't export anything, just parse and test procfile){!}")
 	info.AddOption(OPT_DISABLE_VALIDATION, "Disable application validation")
+	info.AddOption(OPT_STRICT, "Report unknown procfile properties {s-}(always enabled for dry start){!}")
 	info.AddOption(OPT_UNINSTALL, "Remove scripts and helpers for a particular application")
 	info.AddOption(OPT_FORMAT, "Format of generated configs", "upstart|systemd")
+	info.AddOption(OPT_OUTPUT, "Format of validation output", "text|json|sarif")
+	info.AddOption(OPT_CHECK, "Check that procfile is formatted {s-}(fmt command only){!}")
+	info.AddOption(OPT_RUN_TASKS, "Run tasks of application after export and uninstall application if any task fails")
 	info.AddOption(OPT_NO_COLOR, "Disable colors in output")
 	info.AddOption(OPT_HELP, "Show this help message")
 	info.AddOption(OPT_VER, "Show version")
 
 	info.AddExample("-p ./myprocfile -f systemd myapp", "Export given procfile to systemd as myapp")
 	info.AddExample("-u -f systemd myapp", "Uninstall myapp from systemd")
+	info.AddExample("-p ./myprocfile -P production -f systemd myapp", "Export given procfile with production profile to systemd as myapp")
+	info.AddExample("-R -p ./myprocfile -f systemd myapp", "Export given procfile to systemd as myapp and run its tasks")
 
 	info.AddExample("-p ./myprocfile -f upstart myapp", "Export given procfile to upstart as myapp")
 	info.AddExample("-u -f upstart myapp", "Uninstall myapp from upstart")
 
+	info.AddExample("-d -o sarif -p ./myprocfile myapp", "Validate given procfile and print result in SARIF format")
+	info.AddExample("-p ./myprocfile -f systemd lint", "Check given procfile for risky configuration")
+	info.AddExample("-p ./myprocfile convert ./myprocfile.v2", "Convert given procfile v1 to v2 format")
+	info.AddExample("-C -p ./myprocfile fmt", "Check that given procfile is formatted")
+	info.AddExample("-p ./myprocfile health", "Check that services from given procfile are ready")
+
 	return info
 }
 
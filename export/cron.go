@@ -0,0 +1,148 @@
+package export
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                           Copyright (c) 2006-2024 FUNBOX                           //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/funbox/init-exporter/procfile"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+const (
+	REGEXP_CALENDAR_TIME     = `\A(?:\*-\*-\* )?(\*|\d{1,2}):(\d{1,2})(?::00)?\z`
+	REGEXP_CALENDAR_WEEKDAYS = `\A((?:Mon|Tue|Wed|Thu|Fri|Sat|Sun)(?:(?:,|\.\.)(?:Mon|Tue|Wed|Thu|Fri|Sat|Sun))*) (.+)\z`
+)
+
+// cronShortcuts contains cron specs for systemd calendar shortcuts
+var cronShortcuts = map[string]string{
+	"minutely": "* * * * *",
+	"hourly":   "0 * * * *",
+	"daily":    "0 0 * * *",
+	"weekly":   "0 0 * * 1",
+	"monthly":  "0 0 1 * *",
+	"yearly":   "0 0 1 1 *",
+	"annually": "0 0 1 1 *",
+}
+
+// cronWeekdays contains numbers of weekdays in cron format
+var cronWeekdays = map[string]string{
+	"Sun": "0", "Mon": "1", "Tue": "2", "Wed": "3",
+	"Thu": "4", "Fri": "5", "Sat": "6",
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// renderCronJob renders cron table line for scheduled job
+func renderCronJob(schedule *procfile.Schedule) (string, error) {
+	spec, err := getCronSpec(schedule)
+
+	if err != nil {
+		return "", err
+	}
+
+	line := fmt.Sprintf(
+		"%s %s /bin/bash %s &>>/var/log/%s/%s.log",
+		spec, schedule.GetUser(), schedule.HelperPath,
+		schedule.Application.Name, schedule.Name,
+	)
+
+	// Percent sign is converted to new line by cron
+	return strings.ReplaceAll(line, "%", `\%`), nil
+}
+
+// getCronSpec converts calendar spec or interval of scheduled job to cron format
+func getCronSpec(schedule *procfile.Schedule) (string, error) {
+	if schedule.Interval != "" {
+		spec := intervalToCron(schedule.GetIntervalSeconds())
+
+		if spec == "" {
+			return "", fmt.Errorf("Interval %s of job %s can't be converted to cron format", schedule.Interval, schedule.Name)
+		}
+
+		return spec, nil
+	}
+
+	spec := calendarToCron(schedule.Calendar)
+
+	if spec == "" {
+		return "", fmt.Errorf("Calendar spec %q of job %s can't be converted to cron format", schedule.Calendar, schedule.Name)
+	}
+
+	return spec, nil
+}
+
+// intervalToCron converts interval in seconds to cron format (interval must
+// divide minute, hour or day without remainder)
+func intervalToCron(interval int) string {
+	switch {
+	case interval == 0 || interval%60 != 0:
+		return ""
+	case interval == 60:
+		return "* * * * *"
+	case interval < 3600 && 3600%interval == 0:
+		return fmt.Sprintf("*/%d * * * *", interval/60)
+	case interval == 3600:
+		return "0 * * * *"
+	case interval < 86400 && 86400%interval == 0 && interval%3600 == 0:
+		return fmt.Sprintf("0 */%d * * *", interval/3600)
+	case interval == 86400:
+		return "0 0 * * *"
+	}
+
+	return ""
+}
+
+// calendarToCron converts simple calendar specs (shortcuts and daily time with
+// optional list of weekdays) to cron format
+func calendarToCron(calendar string) string {
+	if cronShortcuts[calendar] != "" {
+		return cronShortcuts[calendar]
+	}
+
+	weekdays := "*"
+	weekdaysMatch := regexp.MustCompile(REGEXP_CALENDAR_WEEKDAYS).FindStringSubmatch(calendar)
+
+	if weekdaysMatch != nil {
+		weekdays = strings.ReplaceAll(weekdaysMatch[1], "..", "-")
+
+		for name, num := range cronWeekdays {
+			weekdays = strings.ReplaceAll(weekdays, name, num)
+		}
+
+		calendar = weekdaysMatch[2]
+	}
+
+	timeMatch := regexp.MustCompile(REGEXP_CALENDAR_TIME).FindStringSubmatch(calendar)
+
+	if timeMatch == nil {
+		return ""
+	}
+
+	hour, minute := timeMatch[1], timeMatch[2]
+	minuteNum, _ := strconv.Atoi(minute)
+
+	if minuteNum > 59 {
+		return ""
+	}
+
+	if hour != "*" {
+		hourNum, _ := strconv.Atoi(hour)
+
+		if hourNum > 23 {
+			return ""
+		}
+
+		hour = strconv.Itoa(hourNum)
+	}
+
+	return fmt.Sprintf("%d %s * * %s", minuteNum, hour, weekdays)
+}
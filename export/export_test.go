@@ -137,9 +137,9 @@ func (s *ExportSuite) TestUpstartExport(c *C) {
 			"",
 			"bash << \"EOF\"",
 			"  mkdir -p /var/log/test_application",
-			"  chown -R service /var/log/test_application",
-			"  chgrp -R service /var/log/test_application",
-			"  chmod -R g+w /var/log/test_application",
+			"  chown service /var/log/test_application",
+			"  chgrp service /var/log/test_application",
+			"  chmod g+w /var/log/test_application",
 			"EOF",
 			"",
 			"end script", ""},
@@ -162,12 +162,16 @@ func (s *ExportSuite) TestUpstartExport(c *C) {
 			"",
 			"limit memlock unlimited unlimited",
 			"",
+			"env INSTANCE=1",
+			"env PORT=8080",
+			"env STAGING=true",
+			"",
 			"script",
 			"  touch /var/log/test_application/serviceA.log",
 			"  chown service /var/log/test_application/serviceA.log",
 			"  chgrp service /var/log/test_application/serviceA.log",
 			"  chmod g+w /var/log/test_application/serviceA.log",
-			fmt.Sprintf("  exec sudo -u service /bin/bash %s/test_application-serviceA1.sh &>>/var/log/test_application/serviceA.log", helperDir),
+			fmt.Sprintf("  exec sudo -E -u service /bin/bash %s/test_application-serviceA1.sh &>>/var/log/test_application/serviceA.log", helperDir),
 			"end script", ""},
 	)
 
@@ -188,12 +192,16 @@ func (s *ExportSuite) TestUpstartExport(c *C) {
 			"",
 			"limit memlock unlimited unlimited",
 			"",
+			"env INSTANCE=2",
+			"env PORT=8081",
+			"env STAGING=true",
+			"",
 			"script",
 			"  touch /var/log/test_application/serviceA.log",
 			"  chown service /var/log/test_application/serviceA.log",
 			"  chgrp service /var/log/test_application/serviceA.log",
 			"  chmod g+w /var/log/test_application/serviceA.log",
-			fmt.Sprintf("  exec sudo -u service /bin/bash %s/test_application-serviceA2.sh &>>/var/log/test_application/serviceA.log", helperDir),
+			fmt.Sprintf("  exec sudo -E -u service /bin/bash %s/test_application-serviceA2.sh &>>/var/log/test_application/serviceA.log", helperDir),
 			"end script", ""},
 	)
 
@@ -214,26 +222,31 @@ func (s *ExportSuite) TestUpstartExport(c *C) {
 			"limit nproc 4096 4096",
 			"",
 			"",
+			"env STAGING=true",
+			"",
 			"script",
 			"  touch /var/log/test_application/serviceB.log",
 			"  chown service /var/log/test_application/serviceB.log",
 			"  chgrp service /var/log/test_application/serviceB.log",
 			"  chmod g+w /var/log/test_application/serviceB.log",
-			fmt.Sprintf("  exec sudo -u service /bin/bash %s/test_application-serviceB.sh &>>/var/log/test_application/serviceB.log", helperDir),
+			fmt.Sprintf("  exec sudo -E -u service /bin/bash %s/test_application-serviceB.sh &>>/var/log/test_application/serviceB.log", helperDir),
 			"end script", ""},
 	)
 
 	c.Assert(serviceAHelper[4:], DeepEquals,
 		[]string{
 			"[[ -r /etc/profile.d/rbenv.sh ]] && source /etc/profile.d/rbenv.sh", "[[ -r /etc/profile.d/pyenv.sh ]] && source /etc/profile.d/pyenv.sh", "",
-			"cd /srv/service/serviceA-dir && exec env STAGING=true /bin/echo 'serviceA:pre' &>>/srv/service/serviceA-dir/log/serviceA.log && exec env STAGING=true /bin/echo 'serviceA' &>>/srv/service/serviceA-dir/log/serviceA.log && exec env STAGING=true /bin/echo 'serviceA:post' &>>/srv/service/serviceA-dir/log/serviceA.log",
+			"cd /srv/service/serviceA-dir && exec /bin/echo 'serviceA:pre' &>>/srv/service/serviceA-dir/log/serviceA.log && exec /bin/echo 'serviceA' &>>/srv/service/serviceA-dir/log/serviceA.log && exec /bin/echo 'serviceA:post' &>>/srv/service/serviceA-dir/log/serviceA.log",
 			""},
 	)
 
 	c.Assert(serviceBHelper[4:], DeepEquals,
 		[]string{
 			"[[ -r /etc/profile.d/rbenv.sh ]] && source /etc/profile.d/rbenv.sh", "[[ -r /etc/profile.d/pyenv.sh ]] && source /etc/profile.d/pyenv.sh", "",
-			"cd /srv/service/working-dir && exec env $(cat /srv/service/working-dir/shared/env.vars 2>/dev/null | xargs) STAGING=true /bin/echo 'serviceB'",
+			"set -a && source /srv/service/working-dir/shared/env.vars && set +a || exit 1",
+			"[[ -r /etc/app.env ]] && set -a && source /etc/app.env && set +a",
+			"",
+			"cd /srv/service/working-dir && exec /bin/echo 'serviceB'",
 			""},
 	)
 
@@ -445,9 +458,9 @@ func (s *ExportSuite) TestSystemdExport(c *C) {
 			"RemainAfterExit=true",
 			"",
 			"ExecStartPre=/bin/mkdir -p /var/log/test_application",
-			"ExecStartPre=/bin/chown -R service /var/log/test_application",
-			"ExecStartPre=/bin/chgrp -R service /var/log/test_application",
-			"ExecStartPre=/bin/chmod -R g+w /var/log/test_application",
+			"ExecStartPre=/bin/chown service /var/log/test_application",
+			"ExecStartPre=/bin/chgrp service /var/log/test_application",
+			"ExecStartPre=/bin/chmod g+w /var/log/test_application",
 			"ExecStart=/bin/echo \"test_application started\"",
 			"ExecStop=/bin/echo \"test_application stopped\"",
 			fmt.Sprintf("ExecReload=/bin/sh -c '/bin/bash %s/test_application.sh'", helperDir),
@@ -485,14 +498,17 @@ func (s *ExportSuite) TestSystemdExport(c *C) {
 			"LimitMEMLOCK=infinity",
 			"",
 			"",
-			"ExecStartPre=/bin/touch /var/log/test_application/serviceA.log",
-			"ExecStartPre=/bin/chown service /var/log/test_application/serviceA.log",
-			"ExecStartPre=/bin/chgrp service /var/log/test_application/serviceA.log",
-			"ExecStartPre=/bin/chmod g+w /var/log/test_application/serviceA.log",
+			"ExecStartPre=+/bin/touch /var/log/test_application/serviceA.log",
+			"ExecStartPre=+/bin/chown service /var/log/test_application/serviceA.log",
+			"ExecStartPre=+/bin/chgrp service /var/log/test_application/serviceA.log",
+			"ExecStartPre=+/bin/chmod g+w /var/log/test_application/serviceA.log",
 			"",
 			"User=service",
 			"Group=service",
 			"WorkingDirectory=/srv/service/serviceA-dir",
+			"Environment=INSTANCE=1",
+			"Environment=PORT=8080",
+			"Environment=STAGING=true",
 			fmt.Sprintf("ExecStart=/bin/sh -c '/bin/bash %s/test_application-serviceA1.sh &>>/var/log/test_application/serviceA.log'", helperDir),
 			"ExecReload=/bin/pkill -SIGHUP -P $MAINPID",
 			""},
@@ -521,14 +537,17 @@ func (s *ExportSuite) TestSystemdExport(c *C) {
 			"LimitMEMLOCK=infinity",
 			"",
 			"",
-			"ExecStartPre=/bin/touch /var/log/test_application/serviceA.log",
-			"ExecStartPre=/bin/chown service /var/log/test_application/serviceA.log",
-			"ExecStartPre=/bin/chgrp service /var/log/test_application/serviceA.log",
-			"ExecStartPre=/bin/chmod g+w /var/log/test_application/serviceA.log",
+			"ExecStartPre=+/bin/touch /var/log/test_application/serviceA.log",
+			"ExecStartPre=+/bin/chown service /var/log/test_application/serviceA.log",
+			"ExecStartPre=+/bin/chgrp service /var/log/test_application/serviceA.log",
+			"ExecStartPre=+/bin/chmod g+w /var/log/test_application/serviceA.log",
 			"",
 			"User=service",
 			"Group=service",
 			"WorkingDirectory=/srv/service/serviceA-dir",
+			"Environment=INSTANCE=2",
+			"Environment=PORT=8081",
+			"Environment=STAGING=true",
 			fmt.Sprintf("ExecStart=/bin/sh -c '/bin/bash %s/test_application-serviceA2.sh &>>/var/log/test_application/serviceA.log'", helperDir),
 			"ExecReload=/bin/pkill -SIGHUP -P $MAINPID",
 			""},
@@ -574,14 +593,17 @@ func (s *ExportSuite) TestSystemdExport(c *C) {
 			"IPAddressAllow=127.0.0.0/8 ::1/128",
 			"IPAddressDeny=0.0.0.0/0 ::/0",
 			"",
-			"ExecStartPre=/bin/touch /var/log/test_application/serviceB.log",
-			"ExecStartPre=/bin/chown service /var/log/test_application/serviceB.log",
-			"ExecStartPre=/bin/chgrp service /var/log/test_application/serviceB.log",
-			"ExecStartPre=/bin/chmod g+w /var/log/test_application/serviceB.log",
+			"ExecStartPre=+/bin/touch /var/log/test_application/serviceB.log",
+			"ExecStartPre=+/bin/chown service /var/log/test_application/serviceB.log",
+			"ExecStartPre=+/bin/chgrp service /var/log/test_application/serviceB.log",
+			"ExecStartPre=+/bin/chmod g+w /var/log/test_application/serviceB.log",
 			"",
 			"User=service",
 			"Group=service",
 			"WorkingDirectory=/srv/service/working-dir",
+			"Environment=STAGING=true",
+			"EnvironmentFile=/srv/service/working-dir/shared/env.vars",
+			"EnvironmentFile=-/etc/app.env",
 			fmt.Sprintf("ExecStart=/bin/sh -c '/bin/bash %s/test_application-serviceB.sh &>>/var/log/test_application/serviceB.log'", helperDir),
 			"",
 			""},
@@ -590,17 +612,22 @@ func (s *ExportSuite) TestSystemdExport(c *C) {
 	c.Assert(serviceAHelper[4:], DeepEquals,
 		[]string{
 			"[[ -r /etc/profile.d/rbenv.sh ]] && source /etc/profile.d/rbenv.sh", "[[ -r /etc/profile.d/pyenv.sh ]] && source /etc/profile.d/pyenv.sh", "",
-			"exec env STAGING=true /bin/echo 'serviceA:pre' &>>/srv/service/serviceA-dir/log/serviceA.log && exec env STAGING=true /bin/echo 'serviceA' &>>/srv/service/serviceA-dir/log/serviceA.log && exec env STAGING=true /bin/echo 'serviceA:post' &>>/srv/service/serviceA-dir/log/serviceA.log",
+			"exec /bin/echo 'serviceA:pre' &>>/srv/service/serviceA-dir/log/serviceA.log && exec /bin/echo 'serviceA' &>>/srv/service/serviceA-dir/log/serviceA.log && exec /bin/echo 'serviceA:post' &>>/srv/service/serviceA-dir/log/serviceA.log",
 			""},
 	)
 
 	c.Assert(serviceBHelper[4:], DeepEquals,
 		[]string{
 			"[[ -r /etc/profile.d/rbenv.sh ]] && source /etc/profile.d/rbenv.sh", "[[ -r /etc/profile.d/pyenv.sh ]] && source /etc/profile.d/pyenv.sh", "",
-			"exec env $(cat /srv/service/working-dir/shared/env.vars 2>/dev/null | xargs) STAGING=true /bin/echo 'serviceB'",
+			"exec /bin/echo 'serviceB'",
 			""},
 	)
 
+	serviceA2HelperData, err := os.ReadFile(helperDir + "/test_application-serviceA2.sh")
+
+	c.Assert(err, IsNil)
+	c.Assert(string(serviceA2HelperData), Matches, "(?s).*exec /bin/echo 'serviceA'.*")
+
 	err = exporter.Uninstall(app)
 
 	c.Assert(err, IsNil)
@@ -612,6 +639,64 @@ func (s *ExportSuite) TestSystemdExport(c *C) {
 	c.Assert(fsutil.IsExist(helperDir+"/test_application-serviceB.sh"), Equals, false)
 }
 
+func (s *ExportSuite) TestSystemdDirectExport(c *C) {
+	helperDir := c.MkDir()
+	targetDir := c.MkDir()
+
+	exporter := NewExporter(&Config{
+		HelperDir:        helperDir,
+		TargetDir:        targetDir,
+		DisableAutoStart: true,
+		DisableReload:    true,
+	}, NewSystemd())
+
+	app := createTestApp(targetDir, helperDir)
+	app.Services = app.Services[1:]
+
+	service := app.Services[0]
+	service.Options.Env["JAVA_OPTS"] = `"-Xmx1g -Dname=100%"`
+	service.PreCmd, service.PreCmdArgs = "/bin/echo 'pre && pre'", nil
+	service.CmdArgs = []string{"/usr/bin/java", "-jar", "my app.jar", `--price=$5 "net"`, ";"}
+	service.Cmd = procfile.QuoteArgs(service.CmdArgs)
+	service.PostCmd, service.PostCmdArgs = "", nil
+
+	err := exporter.Install(app)
+
+	c.Assert(err, IsNil)
+	c.Assert(fsutil.IsExist(helperDir+"/test_application-serviceB.sh"), Equals, false)
+
+	unitData, err := os.ReadFile(targetDir + "/test_application-serviceB.service")
+
+	c.Assert(err, IsNil)
+
+	unit := strings.Split(string(unitData), "\n")
+
+	c.Assert(unit[len(unit)-11:], DeepEquals, []string{
+		"WorkingDirectory=/srv/service/working-dir",
+		`Environment="JAVA_OPTS=-Xmx1g -Dname=100%%"`,
+		"Environment=STAGING=true",
+		"EnvironmentFile=/srv/service/working-dir/shared/env.vars",
+		"EnvironmentFile=-/etc/app.env",
+		"StandardOutput=append:/var/log/test_application/serviceB.log",
+		"StandardError=append:/var/log/test_application/serviceB.log",
+		`ExecStartPre=/bin/bash -c "/bin/echo 'pre && pre'"`,
+		`ExecStart=/usr/bin/java -jar "my app.jar" "--price=$$5 \"net\"" \;`,
+		"",
+		"",
+	})
+
+	// Post command is executed by helper only after successful exit of command
+	service.PostCmdArgs = []string{"/bin/echo", "post"}
+	service.PostCmd = procfile.QuoteArgs(service.PostCmdArgs)
+
+	c.Assert(exporter.Install(app), IsNil)
+
+	helperData, err := os.ReadFile(helperDir + "/test_application-serviceB.sh")
+
+	c.Assert(err, IsNil)
+	c.Assert(string(helperData), Matches, `(?s).*exec /usr/bin/java -jar 'my app.jar' .* && exec /bin/echo post\n`)
+}
+
 func (s *ExportSuite) TestSystemdExportWithNet(c *C) {
 	helperDir := c.MkDir()
 	targetDir := c.MkDir()
@@ -708,6 +793,659 @@ This is free software; see the source for copying conditions.  There is NO warra
 	c.Assert(v.Patch(), Equals, 5)
 }
 
+func (s *ExportSuite) TestServiceDependencies(c *C) {
+	app := createTestApp(c.MkDir(), c.MkDir())
+	serviceB := app.Services[1]
+	serviceB.DependsOn = []string{"serviceA"}
+
+	unit, err := NewSystemd().RenderServiceTemplate(serviceB)
+
+	c.Assert(err, IsNil)
+	c.Assert(strings.Split(unit, "\n")[2:8], DeepEquals, []string{
+		"[Unit]",
+		"",
+		"Description=Unit for serviceB service (part of test_application application)",
+		"PartOf=test_application.service",
+		"After=test_application-serviceA1.service test_application-serviceA2.service",
+		"Requires=test_application-serviceA1.service test_application-serviceA2.service",
+	})
+
+	unit, err = NewUpstart().RenderServiceTemplate(serviceB)
+
+	c.Assert(err, IsNil)
+	c.Assert(strings.Split(unit, "\n")[2:4], DeepEquals, []string{
+		"start on started test_application-serviceA1 and started test_application-serviceA2",
+		"stop on stopping test_application",
+	})
+
+	unit, err = NewSystemd().RenderServiceTemplate(app.Services[0])
+
+	c.Assert(err, IsNil)
+	c.Assert(strings.Split(unit, "\n")[5:8], DeepEquals, []string{
+		"PartOf=test_application.service",
+		"",
+		"[Service]",
+	})
+}
+
+func (s *ExportSuite) TestServicePriority(c *C) {
+	app := createTestApp(c.MkDir(), c.MkDir())
+	serviceA, serviceB := app.Services[0], app.Services[1]
+	serviceA.Priority = -1
+	serviceB.DependsOn = []string{"serviceA"}
+
+	c.Assert(NewSystemd().renderServiceDeps(serviceA), Equals, "")
+	c.Assert(NewSystemd().renderServiceDeps(serviceB), Equals,
+		"After=test_application-serviceA1.service test_application-serviceA2.service\n"+
+			"Requires=test_application-serviceA1.service test_application-serviceA2.service",
+	)
+
+	serviceB.DependsOn = nil
+
+	c.Assert(NewSystemd().renderServiceDeps(serviceB), Equals,
+		"After=test_application-serviceA1.service test_application-serviceA2.service",
+	)
+	c.Assert(NewUpstart().renderServiceStartLevel(serviceB), Equals,
+		"started test_application-serviceA1 and started test_application-serviceA2",
+	)
+	c.Assert(NewUpstart().renderServiceStartLevel(serviceA), Equals, "starting test_application")
+}
+
+func (s *ExportSuite) TestHealthCheck(c *C) {
+	app := createTestApp(c.MkDir(), c.MkDir())
+	service := app.Services[1]
+
+	checker, err := NewSystemd().RenderCheckerTemplate(service)
+
+	c.Assert(err, IsNil)
+	c.Assert(checker, Equals, "")
+
+	service.HealthCheck = &procfile.HealthCheck{TCP: "8080", Interval: 1, Retries: 3}
+	service.CheckerPath = "/helpers/test_application-serviceB-health.sh"
+
+	c.Assert(GetHealthCheckCommand(service), Equals, "timeout 3 /bin/bash -c '</dev/tcp/127.0.0.1/8080'")
+
+	checker, err = NewSystemd().RenderCheckerTemplate(service)
+
+	c.Assert(err, IsNil)
+	c.Assert(strings.Split(checker, "\n")[4:17], DeepEquals, []string{
+		"check() {",
+		"  timeout 3 /bin/bash -c '</dev/tcp/127.0.0.1/8080'",
+		"}",
+		"",
+		"for attempt in $(seq 1 3) ; do",
+		"  if check &>/dev/null ; then",
+		"    exit 0",
+		"  fi",
+		"",
+		"  if [[ $attempt -lt 3 ]] ; then",
+		"    sleep 1",
+		"  fi",
+		"done",
+	})
+
+	unit, err := NewSystemd().RenderServiceTemplate(service)
+
+	c.Assert(err, IsNil)
+	c.Assert(unit, Matches, "(?s).*\nExecStartPost=/bin/bash /helpers/test_application-serviceB-health.sh\nTimeoutStartSec=12\n.*")
+
+	unit, err = NewUpstart().RenderServiceTemplate(service)
+
+	c.Assert(err, IsNil)
+	c.Assert(unit, Matches, "(?s).*\npost-start script\n  exec sudo -u service /bin/bash /helpers/test_application-serviceB-health.sh &>>/var/log/test_application/serviceB.log\nend script\n")
+
+	service.HealthCheck = &procfile.HealthCheck{HTTP: "http://127.0.0.1:8080/health"}
+
+	c.Assert(GetHealthCheckCommand(service), Equals, "curl -fsS -o /dev/null --max-time 3 http://127.0.0.1:8080/health")
+
+	service.HealthCheck = &procfile.HealthCheck{Command: "test -f ready", Timeout: 5}
+
+	c.Assert(GetHealthCheckCommand(service), Equals, "cd /srv/service/working-dir && timeout 5 /bin/bash -c 'test -f ready'")
+}
+
+func (s *ExportSuite) TestServiceType(c *C) {
+	app := createTestApp(c.MkDir(), c.MkDir())
+	service := app.Services[1]
+	service.Options.Type = "notify"
+	service.Options.WatchdogSec = 30
+
+	unit, err := NewSystemd().RenderServiceTemplate(service)
+
+	c.Assert(err, IsNil)
+	c.Assert(unit, Matches, "(?s).*\nType=notify\nNotifyAccess=all\nWatchdogSec=30\n\n.*")
+
+	service.CmdArgs = []string{"/bin/server", "--notify"}
+
+	unit, err = NewSystemd().RenderServiceTemplate(service)
+
+	c.Assert(err, IsNil)
+	c.Assert(unit, Matches, "(?s).*\nType=notify\nWatchdogSec=30\n\n.*")
+
+	service.Options.NotifyAccess = "exec"
+
+	unit, err = NewSystemd().RenderServiceTemplate(service)
+
+	c.Assert(err, IsNil)
+	c.Assert(unit, Matches, "(?s).*\nType=notify\nNotifyAccess=exec\nWatchdogSec=30\n\n.*")
+
+	service.Options = &procfile.ServiceOptions{Type: "forking", PIDFile: "/run/server.pid"}
+
+	unit, err = NewSystemd().RenderServiceTemplate(service)
+
+	c.Assert(err, IsNil)
+	c.Assert(unit, Matches, "(?s).*\nType=forking\nPIDFile=/run/server.pid\n\n.*")
+}
+
+func (s *ExportSuite) TestSecurity(c *C) {
+	app := createTestApp(c.MkDir(), c.MkDir())
+	service := app.Services[1]
+	service.Options.Security = &procfile.Security{Preset: procfile.SECURITY_PRESET_DEFAULT, PrivateDevices: "no"}
+
+	unit, err := NewSystemd().RenderServiceTemplate(service)
+
+	c.Assert(err, IsNil)
+	c.Assert(unit, Matches, "(?s).*\nNoNewPrivileges=yes\nProtectSystem=full\nPrivateTmp=yes\nPrivateDevices=no\n\nExecStartPre.*")
+
+	service.Options.Security = &procfile.Security{
+		Preset:              procfile.SECURITY_PRESET_STRICT,
+		ReadWritePaths:      []string{"/srv/data"},
+		AmbientCapabilities: []string{"CAP_NET_BIND_SERVICE"},
+	}
+
+	unit, err = NewSystemd().RenderServiceTemplate(service)
+
+	c.Assert(err, IsNil)
+	c.Assert(unit, Matches, "(?s).*\nProtectSystem=strict\nProtectHome=yes\n.*")
+	c.Assert(unit, Matches, "(?s).*\nReadWritePaths=/srv/data -/var/log/test_application\n.*")
+	c.Assert(unit, Matches, "(?s).*\nCapabilityBoundingSet=\nAmbientCapabilities=CAP_NET_BIND_SERVICE\n.*")
+	c.Assert(unit, Matches, "(?s).*\nSystemCallFilter=@system-service\nRestrictAddressFamilies=AF_UNIX AF_INET AF_INET6\n.*")
+
+	service.Options.Security = &procfile.Security{Preset: procfile.SECURITY_PRESET_NONE}
+
+	unit, err = NewSystemd().RenderServiceTemplate(service)
+
+	c.Assert(err, IsNil)
+	c.Assert(unit, Not(Matches), "(?s).*NoNewPrivileges.*")
+}
+
+func (s *ExportSuite) TestServiceUser(c *C) {
+	app := createTestApp(c.MkDir(), c.MkDir())
+	service := app.Services[1]
+	service.Options.User = "sidekiq"
+	service.Options.Group = "workers"
+
+	unit, err := NewSystemd().RenderServiceTemplate(service)
+
+	c.Assert(err, IsNil)
+	// Log file is prepared by root, because service user can't write to
+	// application log directory
+	c.Assert(unit, Matches, "(?s).*\n"+
+		"ExecStartPre=\\+/bin/touch /var/log/test_application/serviceB.log\n"+
+		"ExecStartPre=\\+/bin/chown sidekiq /var/log/test_application/serviceB.log\n"+
+		"ExecStartPre=\\+/bin/chgrp workers /var/log/test_application/serviceB.log\n"+
+		"ExecStartPre=\\+/bin/chmod g\\+w /var/log/test_application/serviceB.log\n.*")
+	c.Assert(unit, Matches, "(?s).*\nUser=sidekiq\nGroup=workers\n.*")
+
+	unit, err = NewUpstart().RenderServiceTemplate(service)
+
+	c.Assert(err, IsNil)
+	c.Assert(unit, Matches, "(?s).*\n  chown sidekiq /var/log/test_application/serviceB.log\n  chgrp workers /var/log/test_application/serviceB.log\n.*")
+	c.Assert(unit, Matches, "(?s).*\n  exec sudo -E -u sidekiq /bin/bash .*")
+
+	unit, err = NewSystemd().RenderServiceTemplate(app.Services[0])
+
+	c.Assert(err, IsNil)
+	c.Assert(unit, Matches, "(?s).*\nUser=service\nGroup=service\n.*")
+}
+
+func (s *ExportSuite) TestSchedules(c *C) {
+	helperDir, targetDir, cronDir := c.MkDir(), c.MkDir(), c.MkDir()
+
+	app := createTestApp(helperDir, targetDir)
+	app.Schedules = []*procfile.Schedule{
+		{
+			Name:        "cleanup",
+			Cmd:         "bin/cleanup --all",
+			Calendar:    "Mon..Fri *-*-* 03:30:00",
+			Application: app,
+			Options: &procfile.ServiceOptions{
+				Env:        map[string]string{"MODE": "full 100%"},
+				WorkingDir: "/srv/service/working-dir",
+			},
+		},
+		{
+			Name:        "sync",
+			Cmd:         "bin/sync",
+			Interval:    "15m",
+			Application: app,
+			Options:     &procfile.ServiceOptions{WorkingDir: "/srv/service/working-dir"},
+		},
+	}
+
+	exporter := NewExporter(&Config{
+		HelperDir: helperDir, TargetDir: targetDir, CronDir: cronDir,
+		DisableAutoStart: true, DisableReload: true,
+	}, NewSystemd())
+
+	c.Assert(exporter.Install(app), IsNil)
+
+	appUnit, _ := os.ReadFile(targetDir + "/test_application.service")
+
+	c.Assert(string(appUnit), Matches, "(?s).*Wants=.* test_application-cleanup.timer test_application-sync.timer\n.*")
+
+	jobUnit, err := os.ReadFile(targetDir + "/test_application-cleanup.service")
+
+	c.Assert(err, IsNil)
+	c.Assert(string(jobUnit), Matches, "(?s).*\nType=oneshot\n.*")
+	c.Assert(string(jobUnit), Matches, "(?s).*\nEnvironment=\"MODE=full 100%%\"\nExecStart=/bin/sh -c '/bin/bash "+helperDir+"/test_application-cleanup.sh &>>/var/log/test_application/cleanup.log'\n")
+
+	timer, err := os.ReadFile(targetDir + "/test_application-cleanup.timer")
+
+	c.Assert(err, IsNil)
+	c.Assert(strings.Split(string(timer), "\n")[7:11], DeepEquals, []string{
+		"[Timer]",
+		"OnCalendar=Mon..Fri *-*-* 03:30:00",
+		"Persistent=true",
+		"Unit=test_application-cleanup.service",
+	})
+
+	timer, err = os.ReadFile(targetDir + "/test_application-sync.timer")
+
+	c.Assert(err, IsNil)
+	c.Assert(strings.Split(string(timer), "\n")[7:11], DeepEquals, []string{
+		"[Timer]",
+		"OnActiveSec=15m",
+		"OnUnitActiveSec=15m",
+		"Unit=test_application-sync.service",
+	})
+
+	c.Assert(fsutil.IsExist(helperDir+"/test_application-sync.sh"), Equals, true)
+	c.Assert(fsutil.IsExist(cronDir+"/test_application"), Equals, false)
+
+	c.Assert(exporter.Uninstall(app), IsNil)
+	c.Assert(fsutil.IsExist(targetDir+"/test_application-sync.timer"), Equals, false)
+	c.Assert(fsutil.IsExist(targetDir+"/test_application-sync.service"), Equals, false)
+	c.Assert(fsutil.IsExist(helperDir+"/test_application-sync.sh"), Equals, false)
+
+	upstartVersionCache, _ = version.Parse("1.13.2")
+
+	exporter = NewExporter(&Config{
+		HelperDir: helperDir, TargetDir: targetDir, CronDir: cronDir,
+		DisableAutoStart: true, DisableReload: true,
+	}, NewUpstart())
+
+	c.Assert(exporter.Install(app), IsNil)
+
+	cron, err := os.ReadFile(cronDir + "/test_application")
+
+	c.Assert(err, IsNil)
+	c.Assert(strings.Split(string(cron), "\n")[2:6], DeepEquals, []string{
+		"SHELL=/bin/bash",
+		"",
+		"30 3 * * 1-5 service /bin/bash " + helperDir + "/test_application-cleanup.sh &>>/var/log/test_application/cleanup.log",
+		"*/15 * * * * service /bin/bash " + helperDir + "/test_application-sync.sh &>>/var/log/test_application/sync.log",
+	})
+
+	helper, err := os.ReadFile(helperDir + "/test_application-cleanup.sh")
+
+	c.Assert(err, IsNil)
+	c.Assert(string(helper), Matches, "(?s).*\nexport MODE='full 100%'\n\ncd .*")
+
+	c.Assert(fsutil.IsExist(targetDir+"/test_application-sync.timer"), Equals, false)
+	c.Assert(exporter.Uninstall(app), IsNil)
+	c.Assert(fsutil.IsExist(cronDir+"/test_application"), Equals, false)
+
+	app.Schedules[1].Interval = "7m"
+
+	c.Assert(NewUpstart().CheckRequirements(app), ErrorMatches, "Interval 7m of job sync can't be converted to cron format")
+}
+
+func (s *ExportSuite) TestSockets(c *C) {
+	helperDir, targetDir := c.MkDir(), c.MkDir()
+
+	app := createTestApp(helperDir, targetDir)
+	service := app.Services[1]
+	service.Sockets = &procfile.Sockets{Stream: []string{"8080", "/run/test.sock"}, Datagram: []string{"5353"}, Backlog: 64}
+
+	exporter := NewExporter(&Config{
+		HelperDir: helperDir, TargetDir: targetDir,
+		DisableAutoStart: true, DisableReload: true,
+	}, NewSystemd())
+
+	c.Assert(exporter.Install(app), IsNil)
+
+	appUnit, _ := os.ReadFile(targetDir + "/test_application.service")
+
+	c.Assert(string(appUnit), Matches, "(?s).*Wants=test_application-serviceA1.service test_application-serviceA2.service test_application-serviceB.socket\n.*")
+
+	socket, err := os.ReadFile(targetDir + "/test_application-serviceB.socket")
+
+	c.Assert(err, IsNil)
+	c.Assert(strings.Split(string(socket), "\n")[2:13], DeepEquals, []string{
+		"[Unit]",
+		"",
+		"Description=Socket for serviceB service (part of test_application application)",
+		"PartOf=test_application.service",
+		"",
+		"[Socket]",
+		"ListenStream=8080",
+		"ListenStream=/run/test.sock",
+		"ListenDatagram=5353",
+		"Backlog=64",
+		"Service=test_application-serviceB.service",
+	})
+
+	unit, err := os.ReadFile(targetDir + "/test_application-serviceB.service")
+
+	c.Assert(err, IsNil)
+	c.Assert(string(unit), Matches, "(?s).*\nAfter=test_application-serviceB.socket\nRequires=test_application-serviceB.socket\n.*")
+
+	c.Assert(exporter.Uninstall(app), IsNil)
+	c.Assert(fsutil.IsExist(targetDir+"/test_application-serviceB.socket"), Equals, false)
+
+	service.Sockets = &procfile.Sockets{Stream: []string{"8080"}, Accept: true}
+
+	c.Assert(exporter.Install(app), IsNil)
+	c.Assert(fsutil.IsExist(targetDir+"/test_application-serviceB.service"), Equals, false)
+	c.Assert(fsutil.IsExist(targetDir+"/test_application-serviceB@.service"), Equals, true)
+
+	socket, _ = os.ReadFile(targetDir + "/test_application-serviceB.socket")
+
+	c.Assert(string(socket), Matches, "(?s).*\nListenStream=8080\nAccept=yes\n")
+
+	helper, _ := os.ReadFile(helperDir + "/test_application.sh")
+
+	c.Assert(string(helper), Matches, "(?s).*\n/bin/systemctl reload-or-restart test_application-serviceA1.service test_application-serviceA2.service test_application-serviceB@\\*.service\n")
+
+	c.Assert(exporter.Uninstall(app), IsNil)
+	c.Assert(NewUpstart().CheckRequirements(app), ErrorMatches, "Socket activation is not supported by upstart \\(service serviceB\\)")
+}
+
+func (s *ExportSuite) TestSecrets(c *C) {
+	secretsDir := c.MkDir()
+
+	os.WriteFile(secretsDir+"/api_key.cred", []byte("k6iUCUh0RJCQyvL8\nk+q0Q0sAAAAAAAAA\n"), 0600)
+	os.WriteFile(secretsDir+"/plain.cred", []byte("my-password!"), 0600)
+
+	app := createTestApp(c.MkDir(), c.MkDir())
+	service := app.Services[1]
+	service.HelperPath = "/var/local/init-exporter/helpers/test_application-serviceB.sh"
+	service.Options.Secrets = []*procfile.Secret{
+		{Name: "api_key", File: secretsDir + "/api_key.cred", Encrypted: true},
+		{Name: "db_password", File: "/etc/test/db_password"},
+	}
+
+	unit, err := NewSystemd().RenderServiceTemplate(service)
+
+	c.Assert(err, IsNil)
+	c.Assert(unit, Matches, "(?s).*\nSetCredentialEncrypted=api_key:k6iUCUh0RJCQyvL8k\\+q0Q0sAAAAAAAAA\nLoadCredential=db_password:/etc/test/db_password\n\nExecStartPre.*")
+
+	helper, err := NewSystemd().RenderHelperTemplate(service)
+
+	c.Assert(err, IsNil)
+	c.Assert(helper, Not(Matches), "(?s).*(db_password|api_key).*")
+
+	service.Options.Secrets[0].File = secretsDir + "/plain.cred"
+
+	_, err = NewSystemd().RenderServiceTemplate(service)
+
+	c.Assert(err, ErrorMatches, "File .*/plain.cred doesn't contain credential encrypted by systemd-creds \\(secret api_key of service serviceB\\)")
+
+	service.Options.Secrets[0].File = secretsDir + "/unknown.cred"
+
+	_, err = NewSystemd().RenderServiceTemplate(service)
+
+	c.Assert(err, ErrorMatches, "Can't read encrypted secret api_key of service serviceB: .*")
+	c.Assert(NewUpstart().CheckRequirements(app), ErrorMatches, "Encrypted secrets are not supported by upstart \\(service serviceB\\)")
+
+	service.Options.Secrets = service.Options.Secrets[1:]
+
+	c.Assert(NewUpstart().CheckRequirements(app), IsNil)
+
+	unit, err = NewUpstart().RenderServiceTemplate(service)
+
+	c.Assert(err, IsNil)
+	c.Assert(unit, Matches, "(?s).*\n"+
+		"  rm -rf /run/credentials/test_application-serviceB\n"+
+		"  install -d -m 0500 -o service -g service /run/credentials/test_application-serviceB\n"+
+		"  install -m 0400 -o service -g service /etc/test/db_password /run/credentials/test_application-serviceB/db_password \\|\\| exit 1\n"+
+		"  exec sudo -E -u service CREDENTIALS_DIRECTORY=/run/credentials/test_application-serviceB /bin/bash .*")
+}
+
+func (s *ExportSuite) TestTasks(c *C) {
+	helperDir, targetDir := c.MkDir(), c.MkDir()
+
+	app := createTestApp(helperDir, targetDir)
+	app.Tasks = []*procfile.Task{
+		{
+			Name:        "migrate",
+			Cmd:         "bin/rake db:migrate",
+			Application: app,
+			Options: &procfile.ServiceOptions{
+				Env:        map[string]string{"RAILS_ENV": "production"},
+				WorkingDir: "/srv/service/working-dir",
+			},
+		},
+		{
+			Name:        "assets",
+			Cmd:         "bin/rake assets:precompile",
+			Application: app,
+			Options:     &procfile.ServiceOptions{WorkingDir: "/srv/service/working-dir"},
+		},
+	}
+
+	exporter := NewExporter(&Config{
+		HelperDir: helperDir, TargetDir: targetDir,
+		DisableAutoStart: true, DisableReload: true,
+	}, NewSystemd())
+
+	c.Assert(exporter.Install(app), IsNil)
+
+	appUnit, _ := os.ReadFile(targetDir + "/test_application.service")
+
+	c.Assert(string(appUnit), Matches, "(?s).*Wants=test_application-migrate.service test_application-assets.service test_application-serviceA1.service .*")
+
+	taskUnit, err := os.ReadFile(targetDir + "/test_application-migrate.service")
+
+	c.Assert(err, IsNil)
+	c.Assert(string(taskUnit), Matches, "(?s).*\nType=oneshot\nRemainAfterExit=true\n.*")
+	c.Assert(string(taskUnit), Matches, "(?s).*\nAfter=test_application.service\n\n.*")
+	c.Assert(string(taskUnit), Matches, "(?s).*\nExecStartPre=\\+/bin/mkdir -p /var/log/test_application\n.*")
+	c.Assert(string(taskUnit), Matches, "(?s).*\nEnvironment=RAILS_ENV=production\nExecStart=/bin/sh -c '/bin/bash "+helperDir+"/test_application-migrate.sh &>>/var/log/test_application/migrate.log'\n")
+	c.Assert(string(taskUnit), Not(Matches), "(?s).*\nRequires=.*")
+
+	taskUnit, err = os.ReadFile(targetDir + "/test_application-assets.service")
+
+	c.Assert(err, IsNil)
+	c.Assert(string(taskUnit), Matches, "(?s).*\nAfter=test_application.service test_application-migrate.service\nRequires=test_application-migrate.service\n.*")
+
+	unit, err := os.ReadFile(targetDir + "/test_application-serviceB.service")
+
+	c.Assert(err, IsNil)
+	c.Assert(string(unit), Matches, "(?s).*\nAfter=test_application-migrate.service test_application-assets.service\nRequires=test_application-migrate.service test_application-assets.service\n.*")
+
+	c.Assert(fsutil.IsExist(helperDir+"/test_application-migrate.sh"), Equals, true)
+	c.Assert(exporter.Uninstall(app), IsNil)
+	c.Assert(fsutil.IsExist(targetDir+"/test_application-migrate.service"), Equals, false)
+	c.Assert(fsutil.IsExist(helperDir+"/test_application-migrate.sh"), Equals, false)
+
+	upstartVersionCache, _ = version.Parse("1.13.2")
+
+	exporter = NewExporter(&Config{
+		HelperDir: helperDir, TargetDir: targetDir,
+		DisableAutoStart: true, DisableReload: true,
+	}, NewUpstart())
+
+	c.Assert(exporter.Install(app), IsNil)
+
+	appUnit, _ = os.ReadFile(targetDir + "/test_application.conf")
+
+	c.Assert(strings.Split(string(appUnit), "\n")[11:14], DeepEquals, []string{
+		"  chmod g+w /var/log/test_application",
+		"  sudo -u service /bin/bash " + helperDir + "/test_application-migrate.sh &>>/var/log/test_application/migrate.log || exit 1",
+		"  sudo -u service /bin/bash " + helperDir + "/test_application-assets.sh &>>/var/log/test_application/assets.log || exit 1",
+	})
+
+	helper, _ := os.ReadFile(helperDir + "/test_application-migrate.sh")
+
+	c.Assert(string(helper), Matches, "(?s).*\nexport RAILS_ENV=production\n.*")
+
+	helper, _ = os.ReadFile(helperDir + "/test_application-serviceB.sh")
+
+	c.Assert(string(helper), Not(Matches), "(?s).*\nexport .*")
+
+	unit, _ = os.ReadFile(targetDir + "/test_application-serviceB.conf")
+
+	c.Assert(string(unit), Matches, "(?s).*\nstart on started test_application\n.*")
+	c.Assert(fsutil.IsExist(targetDir+"/test_application-migrate.conf"), Equals, false)
+	c.Assert(exporter.Uninstall(app), IsNil)
+
+	// Application with failed task is uninstalled
+	exporter = NewExporter(&Config{
+		HelperDir: helperDir, TargetDir: targetDir,
+		DisableAutoStart: true, DisableReload: true, RunTasks: true,
+	}, &failingTaskProvider{NewSystemd()})
+
+	c.Assert(exporter.Install(app), ErrorMatches, "Task migrate failed")
+	c.Assert(exporter.IsInstalled(app), Equals, false)
+	c.Assert(fsutil.IsExist(targetDir+"/test_application-migrate.service"), Equals, false)
+	c.Assert(fsutil.IsExist(helperDir+"/test_application-migrate.sh"), Equals, false)
+}
+
+func (s *ExportSuite) TestLogrotate(c *C) {
+	helperDir, targetDir, logrotateDir := c.MkDir(), c.MkDir(), c.MkDir()
+
+	app := createTestApp(helperDir, targetDir)
+	app.Logrotate = &procfile.Logrotate{Period: "daily", Rotate: 7, MaxSize: "100M", Compress: true}
+	app.Services[0].Options.LogFile = "log/serviceA-${INSTANCE}.log"
+	app.Services[1].Options.LogFile = "/var/log/test_application/serviceB.log"
+	app.Tasks = []*procfile.Task{
+		{
+			Name:        "migrate",
+			Cmd:         "bin/migrate",
+			Options:     &procfile.ServiceOptions{User: "migrator", Group: "migrator", LogFile: "/srv/logs/migrate.log"},
+			Application: app,
+		},
+	}
+
+	exporter := NewExporter(&Config{
+		HelperDir: helperDir, TargetDir: targetDir, LogrotateDir: logrotateDir,
+		DisableAutoStart: true, DisableReload: true,
+	}, NewSystemd())
+
+	c.Assert(exporter.Install(app), IsNil)
+
+	data, err := os.ReadFile(logrotateDir + "/test_application")
+
+	c.Assert(err, IsNil)
+	c.Assert(strings.Split(string(data), "\n")[1:], DeepEquals, []string{
+		"",
+		"/var/log/test_application/serviceA.log /srv/service/serviceA-dir/log/serviceA-1.log /srv/service/serviceA-dir/log/serviceA-2.log /var/log/test_application/serviceB.log {",
+		"  daily",
+		"  rotate 7",
+		"  maxsize 100M",
+		"  missingok",
+		"  notifempty",
+		"  copytruncate",
+		"  compress",
+		"  delaycompress",
+		"  su service service",
+		"}",
+		"",
+		"/var/log/test_application/migrate.log /srv/logs/migrate.log {",
+		"  daily",
+		"  rotate 7",
+		"  maxsize 100M",
+		"  missingok",
+		"  notifempty",
+		"  copytruncate",
+		"  compress",
+		"  delaycompress",
+		"  su migrator migrator",
+		"}",
+		"",
+	})
+
+	c.Assert(exporter.Uninstall(app), IsNil)
+	c.Assert(fsutil.IsExist(logrotateDir+"/test_application"), Equals, false)
+
+	app.Logrotate = &procfile.Logrotate{IsDisabled: true}
+
+	c.Assert(exporter.Install(app), IsNil)
+	c.Assert(fsutil.IsExist(logrotateDir+"/test_application"), Equals, false)
+}
+
+func (s *ExportSuite) TestCronSpecs(c *C) {
+	c.Assert(intervalToCron(30), Equals, "")
+	c.Assert(intervalToCron(60), Equals, "* * * * *")
+	c.Assert(intervalToCron(600), Equals, "*/10 * * * *")
+	c.Assert(intervalToCron(420), Equals, "")
+	c.Assert(intervalToCron(3600), Equals, "0 * * * *")
+	c.Assert(intervalToCron(6*3600), Equals, "0 */6 * * *")
+	c.Assert(intervalToCron(86400), Equals, "0 0 * * *")
+	c.Assert(intervalToCron(2*86400), Equals, "")
+
+	c.Assert(calendarToCron("daily"), Equals, "0 0 * * *")
+	c.Assert(calendarToCron("weekly"), Equals, "0 0 * * 1")
+	c.Assert(calendarToCron("*-*-* 04:05:00"), Equals, "5 4 * * *")
+	c.Assert(calendarToCron("*:30"), Equals, "30 * * * *")
+	c.Assert(calendarToCron("Sat,Sun 10:00"), Equals, "0 10 * * 6,0")
+	c.Assert(calendarToCron("*-*-01 00:00:00"), Equals, "")
+	c.Assert(calendarToCron("25:00"), Equals, "")
+}
+
+func (s *ExportSuite) TestEnvEscaping(c *C) {
+	c.Assert(unquoteEnvValue(`"a b"`), Equals, "a b")
+	c.Assert(unquoteEnvValue(`'a #b'`), Equals, "a #b")
+	c.Assert(unquoteEnvValue(`"a`), Equals, `"a`)
+
+	c.Assert(escapeSystemdValue("A=1"), Equals, "A=1")
+	c.Assert(escapeSystemdValue(`A=a "b" #c`), Equals, `"A=a \"b\" #c"`)
+
+	c.Assert(escapeUpstartValue("true"), Equals, "true")
+	c.Assert(escapeUpstartValue(""), Equals, `""`)
+	c.Assert(escapeUpstartValue(`a "b" \c #d`), Equals, `"a \"b\" \\c #d"`)
+}
+
+func (s *ExportSuite) TestShellEnv(c *C) {
+	app := createTestApp(c.MkDir(), c.MkDir())
+	service := app.Services[1]
+	service.HelperPath = "/var/local/init-exporter/helpers/test_application-serviceB.sh"
+	service.Options.Env["PATH"] = "$PATH:/usr/local/bin"
+	service.Options.Env["JAVA_OPTS"] = `"${JAVA_OPTS} -Xmx1g"`
+	service.CmdArgs = []string{"/usr/bin/java", "-jar", "app.jar"}
+	service.Cmd = procfile.QuoteArgs(service.CmdArgs)
+
+	c.Assert(service.IsDirectExec(), Equals, false)
+
+	unit, err := NewSystemd().RenderServiceTemplate(service)
+
+	c.Assert(err, IsNil)
+	c.Assert(unit, Not(Matches), "(?s).*(PATH|JAVA_OPTS).*")
+	c.Assert(unit, Matches, "(?s).*\nEnvironment=STAGING=true\n.*")
+
+	helper, err := NewSystemd().RenderHelperTemplate(service)
+
+	c.Assert(err, IsNil)
+	c.Assert(helper, Matches, "(?s).*\nexport JAVA_OPTS=\"\\$\\{JAVA_OPTS\\} -Xmx1g\"\nexport PATH=\\$PATH:/usr/local/bin\n\nexec /usr/bin/java -jar app.jar\n")
+
+	unit, err = NewUpstart().RenderServiceTemplate(service)
+
+	c.Assert(err, IsNil)
+	c.Assert(unit, Not(Matches), "(?s).*(PATH|JAVA_OPTS).*")
+	c.Assert(unit, Matches, "(?s).*\nenv STAGING=true\n.*")
+
+	helper, err = NewUpstart().RenderHelperTemplate(service)
+
+	c.Assert(err, IsNil)
+	c.Assert(helper, Matches, "(?s).*\nexport JAVA_OPTS=\"\\$\\{JAVA_OPTS\\} -Xmx1g\"\nexport PATH=\\$PATH:/usr/local/bin\n\ncd /srv/service/working-dir && exec /usr/bin/java -jar app.jar\n")
+
+	delete(service.Options.Env, "PATH")
+	delete(service.Options.Env, "JAVA_OPTS")
+
+	c.Assert(service.IsDirectExec(), Equals, true)
+}
+
 func (s *ExportSuite) TestWantsClauseGeneration(c *C) {
 	var services []string
 
@@ -756,6 +1494,7 @@ func createTestApp(helperDir, targetDir string) *procfile.Application {
 		Cmd:         "/bin/echo 'serviceA'",
 		PreCmd:      "/bin/echo 'serviceA:pre'",
 		PostCmd:     "/bin/echo 'serviceA:post'",
+		Port:        8080,
 		Application: app,
 		Options: &procfile.ServiceOptions{
 			Env:              map[string]string{"STAGING": "true"},
@@ -779,7 +1518,7 @@ func createTestApp(helperDir, targetDir string) *procfile.Application {
 		Cmd:         "/bin/echo 'serviceB'",
 		Application: app,
 		Options: &procfile.ServiceOptions{
-			EnvFile:          "shared/env.vars",
+			EnvFiles:         []procfile.EnvFile{{Path: "shared/env.vars", Required: true}, {Path: "/etc/app.env"}},
 			Env:              map[string]string{"STAGING": "true"},
 			WorkingDir:       "/srv/service/working-dir",
 			IsRespawnEnabled: true,
@@ -812,3 +1551,14 @@ func createTestApp(helperDir, targetDir string) *procfile.Application {
 
 	return app
 }
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// failingTaskProvider is systemd provider which fails on task execution
+type failingTaskProvider struct {
+	*SystemdProvider
+}
+
+func (p *failingTaskProvider) RunTask(task *procfile.Task) error {
+	return fmt.Errorf("Task %s failed", task.Name)
+}
@@ -8,7 +8,9 @@ package export
 
 import (
 	"fmt"
+	"maps"
 	"os"
+	"slices"
 	"strconv"
 
 	"github.com/essentialkaos/ek/v13/fsutil"
@@ -23,8 +25,11 @@ import (
 type Config struct {
 	HelperDir        string
 	TargetDir        string
+	CronDir          string
+	LogrotateDir     string
 	DisableAutoStart bool
 	DisableReload    bool
+	RunTasks         bool
 }
 
 type Exporter struct {
@@ -56,6 +61,14 @@ func (e *Exporter) Install(app *procfile.Application) error {
 		}
 	}
 
+	// Tasks are written before application unit, because paths to their
+	// helpers are required for rendering application unit
+	err = e.writeTasks(app)
+
+	if err != nil {
+		return err
+	}
+
 	err = e.writeAppUnit(app)
 
 	if err != nil {
@@ -72,14 +85,16 @@ func (e *Exporter) Install(app *procfile.Application) error {
 
 	log.Debug("Service %s units created", app.Name)
 
-	if !e.Config.DisableAutoStart {
-		err = e.Provider.EnableService(app.Name)
+	err = e.writeSchedules(app)
 
-		if err != nil {
-			return err
-		}
+	if err != nil {
+		return err
+	}
 
-		log.Debug("Service %s enabled", app.Name)
+	err = e.writeLogrotate(app)
+
+	if err != nil {
+		return err
 	}
 
 	if !e.Config.DisableReload {
@@ -92,6 +107,32 @@ func (e *Exporter) Install(app *procfile.Application) error {
 		log.Debug("Units reloaded")
 	}
 
+	// Tasks are executed before application is enabled, application with
+	// failed task is removed, so it can't be started with broken state
+	if e.Config.RunTasks && app.HasTasks() {
+		err = e.RunTasks(app)
+
+		if err != nil {
+			uninstallErr := e.Uninstall(app)
+
+			if uninstallErr != nil {
+				log.Error("Can't uninstall service %s: %v", app.Name, uninstallErr)
+			}
+
+			return err
+		}
+	}
+
+	if !e.Config.DisableAutoStart {
+		err = e.Provider.EnableService(app.Name)
+
+		if err != nil {
+			return err
+		}
+
+		log.Debug("Service %s enabled", app.Name)
+	}
+
 	return nil
 }
 
@@ -138,6 +179,30 @@ func (e *Exporter) Uninstall(app *procfile.Application) error {
 
 	log.Debug("Helpers deleted")
 
+	cronPath := e.cronPath(app.Name)
+
+	if e.Config.CronDir != "" && fsutil.IsExist(cronPath) {
+		err = os.Remove(cronPath)
+
+		if err != nil {
+			return err
+		}
+
+		log.Debug("Cron table %s deleted", cronPath)
+	}
+
+	logrotatePath := e.logrotatePath(app.Name)
+
+	if e.Config.LogrotateDir != "" && fsutil.IsExist(logrotatePath) {
+		err = os.Remove(logrotatePath)
+
+		if err != nil {
+			return err
+		}
+
+		log.Debug("Logrotate configuration %s deleted", logrotatePath)
+	}
+
 	if !e.Config.DisableReload {
 		err = e.Provider.Reload()
 
@@ -151,6 +216,24 @@ func (e *Exporter) Uninstall(app *procfile.Application) error {
 	return nil
 }
 
+// RunTasks executes all tasks of application one by one and returns error if
+// any of them fails
+func (e *Exporter) RunTasks(app *procfile.Application) error {
+	for _, task := range app.Tasks {
+		log.Debug("Running task %s", task.Name)
+
+		err := e.Provider.RunTask(task)
+
+		if err != nil {
+			return err
+		}
+
+		log.Debug("Task %s finished", task.Name)
+	}
+
+	return nil
+}
+
 // IsInstalled return true if app already installed
 func (e *Exporter) IsInstalled(app *procfile.Application) bool {
 	return fsutil.IsExist(e.unitPath(app.Name))
@@ -232,22 +315,44 @@ func (e *Exporter) writeServicesUnits(app *procfile.Application) error {
 func (e *Exporter) writeServiceUnit(service *procfile.Service, appName, index string) error {
 	fullServiceName := appName + "-" + service.Name + index
 
+	service = service.WithInstance(index)
 	service.HelperPath = e.helperPath(fullServiceName)
 
+	if service.HasHealthCheck() {
+		service.CheckerPath = e.helperPath(fullServiceName + "-health")
+	}
+
 	helperData, err := e.Provider.RenderHelperTemplate(service)
 
 	if err != nil {
 		return err
 	}
 
+	checkerData, err := e.Provider.RenderCheckerTemplate(service)
+
+	if err != nil {
+		return err
+	}
+
 	unitData, err := e.Provider.RenderServiceTemplate(service)
 
 	if err != nil {
 		return err
 	}
 
+	socketData, err := e.Provider.RenderSocketTemplate(service)
+
+	if err != nil {
+		return err
+	}
+
 	unitPath := e.unitPath(fullServiceName)
 
+	// Service started for every connection must be a template unit
+	if service.HasSockets() && service.Sockets.Accept {
+		unitPath = e.unitPath(fullServiceName + "@")
+	}
+
 	err = os.WriteFile(unitPath, []byte(unitData), 0644)
 
 	if err != nil {
@@ -260,6 +365,32 @@ func (e *Exporter) writeServiceUnit(service *procfile.Service, appName, index st
 		log.Debug("Unit for %s (%s) saved as %s", service.Name, index, unitPath)
 	}
 
+	if socketData != "" {
+		socketPath := path.Join(e.Config.TargetDir, fullServiceName+".socket")
+		err = os.WriteFile(socketPath, []byte(socketData), 0644)
+
+		if err != nil {
+			return err
+		}
+
+		log.Debug("Socket for %s saved as %s", service.Name, socketPath)
+	}
+
+	if checkerData != "" {
+		err = os.WriteFile(service.CheckerPath, []byte(checkerData), 0644)
+
+		if err != nil {
+			return err
+		}
+
+		log.Debug("Health checker for %s saved as %s", service.Name, service.CheckerPath)
+	}
+
+	// Services executed directly by init system don't need helper
+	if helperData == "" {
+		return nil
+	}
+
 	err = os.WriteFile(service.HelperPath, []byte(helperData), 0644)
 
 	if err != nil {
@@ -275,11 +406,165 @@ func (e *Exporter) writeServiceUnit(service *procfile.Service, appName, index st
 	return nil
 }
 
+// writeTasks writes units and helpers for tasks
+func (e *Exporter) writeTasks(app *procfile.Application) error {
+	if !app.HasTasks() {
+		return nil
+	}
+
+	err := os.MkdirAll(e.Config.HelperDir, 0755)
+
+	if err != nil {
+		return err
+	}
+
+	for _, task := range app.Tasks {
+		task.HelperPath = e.helperPath(app.Name + "-" + task.Name)
+
+		helperData, err := e.Provider.RenderHelperTemplate(task.AsService())
+
+		if err != nil {
+			return err
+		}
+
+		if helperData != "" {
+			err = os.WriteFile(task.HelperPath, []byte(helperData), 0644)
+
+			if err != nil {
+				return err
+			}
+
+			log.Debug("Helper for task %s saved as %s", task.Name, task.HelperPath)
+		}
+
+		unitData, err := e.Provider.RenderTaskTemplate(task)
+
+		if err != nil {
+			return err
+		}
+
+		if unitData == "" {
+			continue
+		}
+
+		unitPath := e.unitPath(app.Name + "-" + task.Name)
+		err = os.WriteFile(unitPath, []byte(unitData), 0644)
+
+		if err != nil {
+			return err
+		}
+
+		log.Debug("Unit for task %s saved as %s", task.Name, unitPath)
+	}
+
+	return nil
+}
+
+// writeSchedules writes units, helpers and cron table for scheduled jobs
+func (e *Exporter) writeSchedules(app *procfile.Application) error {
+	for _, schedule := range app.Schedules {
+		schedule.HelperPath = e.helperPath(app.Name + "-" + schedule.Name)
+
+		helperData, err := e.Provider.RenderHelperTemplate(schedule.AsService())
+
+		if err != nil {
+			return err
+		}
+
+		if helperData != "" {
+			err = os.WriteFile(schedule.HelperPath, []byte(helperData), 0644)
+
+			if err != nil {
+				return err
+			}
+
+			log.Debug("Helper for job %s saved as %s", schedule.Name, schedule.HelperPath)
+		}
+
+		units, err := e.Provider.RenderScheduleTemplates(schedule)
+
+		if err != nil {
+			return err
+		}
+
+		for _, name := range slices.Sorted(maps.Keys(units)) {
+			unitPath := path.Join(e.Config.TargetDir, name)
+			err = os.WriteFile(unitPath, []byte(units[name]), 0644)
+
+			if err != nil {
+				return err
+			}
+
+			log.Debug("Unit for job %s saved as %s", schedule.Name, unitPath)
+		}
+	}
+
+	cronData, err := e.Provider.RenderCronTemplate(app)
+
+	if err != nil || cronData == "" {
+		return err
+	}
+
+	if e.Config.CronDir == "" {
+		return fmt.Errorf("Can't save cron table: directory for cron tables is not set")
+	}
+
+	cronPath := e.cronPath(app.Name)
+	err = os.WriteFile(cronPath, []byte(cronData), 0644)
+
+	if err != nil {
+		return err
+	}
+
+	log.Debug("Cron table saved as %s", cronPath)
+
+	return nil
+}
+
+// writeLogrotate writes logrotate configuration for logs of application
+func (e *Exporter) writeLogrotate(app *procfile.Application) error {
+	if e.Config.LogrotateDir == "" || !app.IsLogrotateEnabled() {
+		return nil
+	}
+
+	if !fsutil.IsExist(e.Config.LogrotateDir) {
+		log.Warn("Directory %s doesn't exist, logrotate configuration is not saved", e.Config.LogrotateDir)
+		return nil
+	}
+
+	data, err := renderLogrotate(app)
+
+	if err != nil {
+		return err
+	}
+
+	logrotatePath := e.logrotatePath(app.Name)
+	err = os.WriteFile(logrotatePath, []byte(data), 0644)
+
+	if err != nil {
+		return err
+	}
+
+	log.Debug("Logrotate configuration saved as %s", logrotatePath)
+
+	return nil
+}
+
 // unitPath returns path for unit
 func (e *Exporter) unitPath(name string) string {
 	return path.Join(e.Config.TargetDir, e.Provider.UnitName(name))
 }
 
+// cronPath returns path for cron table
+func (e *Exporter) cronPath(name string) string {
+	return path.Join(e.Config.CronDir, name)
+}
+
+// logrotatePath returns path for logrotate configuration
+func (e *Exporter) logrotatePath(name string) string {
+	return path.Join(e.Config.LogrotateDir, name)
+}
+
 // helperPath returns path for helper
 func (e *Exporter) helperPath(name string) string {
 	return path.Join(e.Config.HelperDir, name+".sh")
@@ -314,3 +599,96 @@ func deleteByMask(dir, mask string) error {
 
 	return nil
 }
+
+// getServiceUnits returns names of units (without extension) for all instances
+// of service
+func getServiceUnits(service *procfile.Service) []string {
+	name := service.Application.Name + "-" + service.Name
+
+	if service.Options.Count <= 0 {
+		return []string{name}
+	}
+
+	var result []string
+
+	for i := 1; i <= service.Options.Count; i++ {
+		result = append(result, name+strconv.Itoa(i))
+	}
+
+	return result
+}
+
+// getDependencyUnits returns names of units (without extension) of all services
+// which given service depends on
+func getDependencyUnits(service *procfile.Service) []string {
+	var result []string
+
+	for _, dep := range getDependencyServices(service) {
+		result = append(result, getServiceUnits(dep)...)
+	}
+
+	return result
+}
+
+// getDependencyServices returns all services which given service depends on
+func getDependencyServices(service *procfile.Service) []*procfile.Service {
+	var result []*procfile.Service
+
+	for _, dep := range service.DependsOn {
+		depService := service.Application.GetService(dep)
+
+		if depService != nil {
+			result = append(result, depService)
+		}
+	}
+
+	return result
+}
+
+// getPriorityUnits returns names of units (without extension) of services with
+// the closest lower priority, which must be started before given service
+func getPriorityUnits(service *procfile.Service) []string {
+	var result []string
+
+	for _, s := range getPriorityServices(service) {
+		result = append(result, getServiceUnits(s)...)
+	}
+
+	return result
+}
+
+// getPriorityServices returns services with the closest lower priority
+func getPriorityServices(service *procfile.Service) []*procfile.Service {
+	var result []*procfile.Service
+	var prevPriority int
+	var found bool
+
+	for _, s := range service.Application.Services {
+		if s.Priority < service.Priority && (!found || s.Priority > prevPriority) {
+			prevPriority, found = s.Priority, true
+		}
+	}
+
+	if !found {
+		return nil
+	}
+
+	for _, s := range service.Application.Services {
+		if s.Priority == prevPriority {
+			result = append(result, s)
+		}
+	}
+
+	return result
+}
+
+// unquoteEnvValue removes shell quoting from environment variable value
+func unquoteEnvValue(value string) string {
+	tokens, err := procfile.Tokenize(value)
+
+	if err != nil || len(tokens) != 1 || tokens[0].Kind != procfile.TOKEN_WORD {
+		return value
+	}
+
+	return tokens[0].Value
+}
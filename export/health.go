@@ -0,0 +1,102 @@
+package export
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                           Copyright (c) 2006-2024 FUNBOX                           //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/essentialkaos/ek/v13/timeutil"
+
+	"github.com/funbox/init-exporter/procfile"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// TEMPLATE_HEALTH_CHECKER contains default health checker template
+const TEMPLATE_HEALTH_CHECKER = `#!/bin/bash
+
+# This checker generated {{.ExportDate}} by init-exporter/{{.Provider}} for {{.Application.Name}} application
+
+check() {
+  {{.Command}}
+}
+
+for attempt in $(seq 1 {{.Check.GetRetries}}) ; do
+  if check &>/dev/null ; then
+    exit 0
+  fi
+
+  if [[ $attempt -lt {{.Check.GetRetries}} ]] ; then
+    sleep {{.Check.GetInterval}}
+  fi
+done
+
+echo "Service {{.Service.Name}} is not ready after {{.Check.GetRetries}} checks"
+
+exit 1
+`
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+type healthCheckerData struct {
+	Application *procfile.Application
+	Service     *procfile.Service
+	Check       *procfile.HealthCheck
+	Command     string
+	Provider    string
+	ExportDate  string
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// GetHealthCheckCommand returns shell command which checks once that service
+// is ready
+func GetHealthCheckCommand(service *procfile.Service) string {
+	check := service.HealthCheck
+	timeout := check.GetTimeout()
+
+	switch {
+	case check.TCP != "":
+		host, port := check.GetTCPAddress()
+		return fmt.Sprintf(
+			"timeout %d /bin/bash -c %s",
+			timeout, procfile.QuoteArg("</dev/tcp/"+host+"/"+port),
+		)
+
+	case check.HTTP != "":
+		return fmt.Sprintf(
+			"curl -fsS -o /dev/null --max-time %d %s",
+			timeout, procfile.QuoteArg(check.HTTP),
+		)
+	}
+
+	return fmt.Sprintf(
+		"cd %s && timeout %d /bin/bash -c %s",
+		procfile.QuoteArg(service.Options.WorkingDir), timeout, procfile.QuoteArg(check.Command),
+	)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// renderHealthChecker renders health checker script for service
+func renderHealthChecker(service *procfile.Service, provider string) (string, error) {
+	if !service.HasHealthCheck() {
+		return "", nil
+	}
+
+	data := &healthCheckerData{
+		Application: service.Application,
+		Service:     service,
+		Check:       service.HealthCheck,
+		Command:     GetHealthCheckCommand(service),
+		Provider:    provider,
+		ExportDate:  timeutil.Format(time.Now(), "%Y/%m/%d %H:%M:%S"),
+	}
+
+	return renderTemplate("health-checker-template", TEMPLATE_HEALTH_CHECKER, data)
+}
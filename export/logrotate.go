@@ -0,0 +1,132 @@
+package export
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                           Copyright (c) 2006-2024 FUNBOX                           //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/essentialkaos/ek/v13/timeutil"
+
+	"github.com/funbox/init-exporter/procfile"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// TEMPLATE_LOGROTATE contains default logrotate configuration template (processes
+// keep log files open, so files are truncated after copying instead of moving)
+const TEMPLATE_LOGROTATE = `# This file generated {{.ExportDate}} by init-exporter for {{.Application.Name}} application
+{{ range .Groups }}
+{{.Paths}} {
+  {{$.Logrotate.Period}}
+  rotate {{$.Logrotate.Rotate}}
+{{ if $.Logrotate.MaxSize }}  maxsize {{$.Logrotate.MaxSize}}
+{{ end }}  missingok
+  notifempty
+  copytruncate
+{{ if $.Logrotate.Compress }}  compress
+  delaycompress
+{{ end }}  su {{.User}} {{.Group}}
+}
+{{ end }}`
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+type logrotateData struct {
+	Application *procfile.Application
+	Logrotate   *procfile.Logrotate
+	ExportDate  string
+	Groups      []*logrotateGroup
+}
+
+// logrotateGroup contains log files owned by the same user
+type logrotateGroup struct {
+	Paths string
+	User  string
+	Group string
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// renderLogrotate renders logrotate configuration for all logs of application
+// (empty if rotation is disabled), logs are grouped by owner, because they are
+// rotated on behalf of owner
+func renderLogrotate(app *procfile.Application) (string, error) {
+	if !app.IsLogrotateEnabled() {
+		return "", nil
+	}
+
+	data := &logrotateData{
+		Application: app,
+		Logrotate:   app.Logrotate,
+		ExportDate:  timeutil.Format(time.Now(), "%Y/%m/%d %H:%M:%S"),
+	}
+
+	groups := map[string]*logrotateGroup{}
+	known := map[string]bool{}
+
+	for _, service := range getLogServices(app) {
+		owner := service.GetUser() + ":" + service.GetGroup()
+
+		for _, logPath := range getLogPaths(service) {
+			if known[logPath] {
+				continue
+			}
+
+			known[logPath] = true
+
+			if groups[owner] == nil {
+				groups[owner] = &logrotateGroup{User: service.GetUser(), Group: service.GetGroup()}
+				data.Groups = append(data.Groups, groups[owner])
+			}
+
+			groups[owner].Paths = strings.TrimSpace(groups[owner].Paths + " " + logPath)
+		}
+	}
+
+	return renderTemplate("logrotate-template", TEMPLATE_LOGROTATE, data)
+}
+
+// getLogServices returns all instances of services, scheduled jobs and tasks
+func getLogServices(app *procfile.Application) []*procfile.Service {
+	var result []*procfile.Service
+
+	for _, service := range app.Services {
+		if service.Options.Count <= 0 {
+			result = append(result, service.WithInstance(""))
+			continue
+		}
+
+		for i := 1; i <= service.Options.Count; i++ {
+			result = append(result, service.WithInstance(strconv.Itoa(i)))
+		}
+	}
+
+	for _, schedule := range app.Schedules {
+		result = append(result, schedule.AsService())
+	}
+
+	for _, task := range app.Tasks {
+		result = append(result, task.AsService())
+	}
+
+	return result
+}
+
+// getLogPaths returns paths to log files of service (log file in application
+// log directory and custom log file)
+func getLogPaths(service *procfile.Service) []string {
+	result := []string{path.Join("/var/log", service.Application.Name, service.Name+".log")}
+
+	if service.Options.IsCustomLogEnabled() {
+		result = append(result, service.Options.FullLogPath())
+	}
+
+	return result
+}
@@ -40,6 +40,29 @@ type Provider interface {
 	// RenderReloadHelperTemplate renders helper template data for reloading services
 	RenderReloadHelperTemplate(app *procfile.Application) (string, error)
 
+	// RenderCheckerTemplate renders health checker script for given service
+	// (empty if service doesn't have health check)
+	RenderCheckerTemplate(service *procfile.Service) (string, error)
+
+	// RenderTaskTemplate renders unit for task executed before services start
+	// (empty if provider doesn't use units for tasks)
+	RenderTaskTemplate(task *procfile.Task) (string, error)
+
+	// RunTask executes task and waits until it is finished
+	RunTask(task *procfile.Task) error
+
+	// RenderSocketTemplate renders socket unit for service with socket activation
+	// (empty if service doesn't use sockets)
+	RenderSocketTemplate(service *procfile.Service) (string, error)
+
+	// RenderScheduleTemplates renders units for scheduled job and returns map
+	// with names and data of units
+	RenderScheduleTemplates(schedule *procfile.Schedule) (map[string]string, error)
+
+	// RenderCronTemplate renders cron table with scheduled jobs of application
+	// (empty if provider doesn't use cron)
+	RenderCronTemplate(app *procfile.Application) (string, error)
+
 	// EnableService enables service with given name
 	EnableService(appName string) error
 
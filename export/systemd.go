@@ -7,9 +7,13 @@ package export
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 import (
+	"encoding/base64"
 	"errors"
 	"fmt"
-	"strconv"
+	"maps"
+	"os"
+	"path"
+	"slices"
 	"strings"
 	"time"
 
@@ -34,7 +38,9 @@ const TEMPLATE_SYSTEMD_HELPER = `#!/bin/bash
 
 [[ -r /etc/profile.d/rbenv.sh ]] && source /etc/profile.d/rbenv.sh
 [[ -r /etc/profile.d/pyenv.sh ]] && source /etc/profile.d/pyenv.sh
-
+{{ if .Service.Options.IsShellEnvSet }}
+{{.Service.Options.ShellEnvString}}
+{{ end }}
 {{ if .Service.HasPreCmd }}{{.Service.GetCommandExec "pre"}} && {{ end }}{{.Service.GetCommandExec ""}}{{ if .Service.HasPostCmd }} && {{.Service.GetCommandExec "post"}}{{ end }}
 `
 
@@ -60,9 +66,9 @@ Type=oneshot
 RemainAfterExit=true
 
 ExecStartPre=/bin/mkdir -p /var/log/{{.Application.Name}}
-ExecStartPre=/bin/chown -R {{.Application.User}} /var/log/{{.Application.Name}}
-ExecStartPre=/bin/chgrp -R {{.Application.Group}} /var/log/{{.Application.Name}}
-ExecStartPre=/bin/chmod -R g+w /var/log/{{.Application.Name}}
+ExecStartPre=/bin/chown {{.Application.User}} /var/log/{{.Application.Name}}
+ExecStartPre=/bin/chgrp {{.Application.Group}} /var/log/{{.Application.Name}}
+ExecStartPre=/bin/chmod g+w /var/log/{{.Application.Name}}
 ExecStart=/bin/echo "{{.Application.Name}} started"
 ExecStop=/bin/echo "{{.Application.Name}} stopped"
 {{ if .Application.IsReloadSignalSet }}ExecReload=/bin/sh -c '/bin/bash {{.ReloadHelper}}'{{end}}
@@ -78,10 +84,12 @@ const TEMPLATE_SYSTEMD_SERVICE = `# This unit generated {{.ExportDate}} by init-
 
 Description=Unit for {{.Service.Name}} service (part of {{.Application.Name}} application)
 PartOf={{.Application.Name}}.service
-
+{{ if .Dependencies }}{{.Dependencies}}
+{{ end }}
 [Service]
-Type=simple
-
+Type={{.Service.Options.GetType}}
+{{ if .TypeOptions }}{{.TypeOptions}}
+{{ end }}
 {{ if .Service.Options.IsKillModeSet }}KillMode={{.Service.Options.KillMode}}{{ end }}
 {{ if .Service.Options.IsKillSignalSet }}KillSignal={{.Service.Options.KillSignal}}{{ end }}
 TimeoutStopSec={{.Service.Options.KillTimeout}}
@@ -94,17 +102,99 @@ TimeoutStopSec={{.Service.Options.KillTimeout}}
 {{ if .Service.Options.IsProcLimitSet }}LimitNPROC={{.Service.Options.LimitProc}}{{ end }}
 {{ if .Service.Options.IsMemlockLimitSet }}LimitMEMLOCK={{.GetMemlockLimit}}{{ end }}
 
-{{ if .Service.Options.IsResourcesSet }}{{.ResourcesAsString}}{{ end }}
-ExecStartPre=/bin/touch /var/log/{{.Application.Name}}/{{.Service.Name}}.log
-ExecStartPre=/bin/chown {{.Application.User}} /var/log/{{.Application.Name}}/{{.Service.Name}}.log
-ExecStartPre=/bin/chgrp {{.Application.Group}} /var/log/{{.Application.Name}}/{{.Service.Name}}.log
-ExecStartPre=/bin/chmod g+w /var/log/{{.Application.Name}}/{{.Service.Name}}.log
+{{ if .Service.Options.IsResourcesSet }}{{.ResourcesAsString}}{{ end }}{{ if .Service.Options.IsSecuritySet }}{{.SecurityAsString}}{{ end }}{{.Credentials}}
+ExecStartPre=+/bin/touch /var/log/{{.Application.Name}}/{{.Service.Name}}.log
+ExecStartPre=+/bin/chown {{.Service.GetUser}} /var/log/{{.Application.Name}}/{{.Service.Name}}.log
+ExecStartPre=+/bin/chgrp {{.Service.GetGroup}} /var/log/{{.Application.Name}}/{{.Service.Name}}.log
+ExecStartPre=+/bin/chmod g+w /var/log/{{.Application.Name}}/{{.Service.Name}}.log
+
+User={{.Service.GetUser}}
+Group={{.Service.GetGroup}}
+WorkingDirectory={{.Service.Options.WorkingDir}}
+{{ if .IsEnvironmentSet }}{{.EnvironmentAsString}}
+{{ end }}{{ if .Service.IsDirectExec }}{{.DirectExec}}{{ else }}ExecStart=/bin/sh -c '/bin/bash {{.Service.HelperPath}} &>>/var/log/{{.Application.Name}}/{{.Service.Name}}.log'{{ end }}
+{{ if .Service.HasHealthCheck }}ExecStartPost=/bin/bash {{.Service.CheckerPath}}
+TimeoutStartSec={{.Service.HealthCheck.GetMaxDuration}}
+{{ end }}{{ if .Service.Options.IsReloadSignalSet }}ExecReload=/bin/pkill -{{.Service.Options.ReloadSignal}} -P $MAINPID{{ end }}
+`
+
+// TEMPLATE_SYSTEMD_JOB contains default scheduled job template
+const TEMPLATE_SYSTEMD_JOB = `# This unit generated {{.ExportDate}} by init-exporter/systemd for {{.Application.Name}} application
+
+[Unit]
+
+Description=Unit for {{.Service.Name}} scheduled job (part of {{.Application.Name}} application)
+PartOf={{.Application.Name}}.service
+
+[Service]
+Type=oneshot
+
+ExecStartPre=+/bin/touch /var/log/{{.Application.Name}}/{{.Service.Name}}.log
+ExecStartPre=+/bin/chown {{.Service.GetUser}} /var/log/{{.Application.Name}}/{{.Service.Name}}.log
+ExecStartPre=+/bin/chgrp {{.Service.GetGroup}} /var/log/{{.Application.Name}}/{{.Service.Name}}.log
+ExecStartPre=+/bin/chmod g+w /var/log/{{.Application.Name}}/{{.Service.Name}}.log
 
-User={{.Application.User}}
-Group={{.Application.Group}}
+User={{.Service.GetUser}}
+Group={{.Service.GetGroup}}
 WorkingDirectory={{.Service.Options.WorkingDir}}
-ExecStart=/bin/sh -c '/bin/bash {{.Service.HelperPath}} &>>/var/log/{{.Application.Name}}/{{.Service.Name}}.log'
-{{ if .Service.Options.IsReloadSignalSet }}ExecReload=/bin/pkill -{{.Service.Options.ReloadSignal}} -P $MAINPID{{ end }}
+{{ if .IsEnvironmentSet }}{{.EnvironmentAsString}}
+{{ end }}{{ if .Service.IsDirectExec }}{{.DirectExec}}{{ else }}ExecStart=/bin/sh -c '/bin/bash {{.Service.HelperPath}} &>>/var/log/{{.Application.Name}}/{{.Service.Name}}.log'{{ end }}
+`
+
+// TEMPLATE_SYSTEMD_TASK contains default task template
+const TEMPLATE_SYSTEMD_TASK = `# This unit generated {{.ExportDate}} by init-exporter/systemd for {{.Application.Name}} application
+
+[Unit]
+
+Description=Unit for {{.Service.Name}} task (part of {{.Application.Name}} application)
+PartOf={{.Application.Name}}.service
+{{.Dependencies}}
+
+[Service]
+Type=oneshot
+RemainAfterExit=true
+
+ExecStartPre=+/bin/mkdir -p /var/log/{{.Application.Name}}
+ExecStartPre=+/bin/touch /var/log/{{.Application.Name}}/{{.Service.Name}}.log
+ExecStartPre=+/bin/chown {{.Service.GetUser}} /var/log/{{.Application.Name}}/{{.Service.Name}}.log
+ExecStartPre=+/bin/chgrp {{.Service.GetGroup}} /var/log/{{.Application.Name}}/{{.Service.Name}}.log
+ExecStartPre=+/bin/chmod g+w /var/log/{{.Application.Name}}/{{.Service.Name}}.log
+
+User={{.Service.GetUser}}
+Group={{.Service.GetGroup}}
+WorkingDirectory={{.Service.Options.WorkingDir}}
+{{ if .IsEnvironmentSet }}{{.EnvironmentAsString}}
+{{ end }}{{ if .Service.IsDirectExec }}{{.DirectExec}}{{ else }}ExecStart=/bin/sh -c '/bin/bash {{.Service.HelperPath}} &>>/var/log/{{.Application.Name}}/{{.Service.Name}}.log'{{ end }}
+`
+
+// TEMPLATE_SYSTEMD_TIMER contains default timer template
+const TEMPLATE_SYSTEMD_TIMER = `# This unit generated {{.ExportDate}} by init-exporter/systemd for {{.Application.Name}} application
+
+[Unit]
+
+Description=Timer for {{.Schedule.Name}} scheduled job (part of {{.Application.Name}} application)
+PartOf={{.Application.Name}}.service
+
+[Timer]
+{{ if .Schedule.Calendar }}OnCalendar={{.Schedule.Calendar}}
+Persistent=true{{ else }}OnActiveSec={{.Schedule.Interval}}
+OnUnitActiveSec={{.Schedule.Interval}}{{ end }}
+Unit={{.Unit}}
+`
+
+// TEMPLATE_SYSTEMD_SOCKET contains default socket template
+const TEMPLATE_SYSTEMD_SOCKET = `# This unit generated {{.ExportDate}} by init-exporter/systemd for {{.Application.Name}} application
+
+[Unit]
+
+Description=Socket for {{.Service.Name}} service (part of {{.Application.Name}} application)
+PartOf={{.Application.Name}}.service
+
+[Socket]
+{{ range .Service.Sockets.Stream }}ListenStream={{.}}
+{{ end }}{{ range .Service.Sockets.Datagram }}ListenDatagram={{.}}
+{{ end }}{{ if gt .Service.Sockets.Backlog 0 }}Backlog={{.Service.Sockets.Backlog}}
+{{ end }}{{ if .Service.Sockets.Accept }}Accept=yes{{ else }}Service={{.Unit}}{{ end }}
 `
 
 // ////////////////////////////////////////////////////////////////////////////////// //
@@ -121,9 +211,25 @@ type systemdAppData struct {
 }
 
 type systemdServiceData struct {
+	Application  *procfile.Application
+	Service      *procfile.Service
+	ExportDate   string
+	Dependencies string
+	Credentials  string
+}
+
+type systemdSocketData struct {
 	Application *procfile.Application
 	Service     *procfile.Service
 	ExportDate  string
+	Unit        string
+}
+
+type systemdTimerData struct {
+	Application *procfile.Application
+	Schedule    *procfile.Schedule
+	ExportDate  string
+	Unit        string
 }
 
 // ////////////////////////////////////////////////////////////////////////////////// //
@@ -212,6 +318,57 @@ func (sd *systemdServiceData) ResourcesAsString() string {
 	return result
 }
 
+// SecurityAsString returns sandboxing settings as string
+func (sd *systemdServiceData) SecurityAsString() string {
+	var result string
+
+	security := sd.Service.Options.Security.Resolve()
+
+	for _, item := range []struct{ name, value string }{
+		{"NoNewPrivileges", security.NoNewPrivileges},
+		{"ProtectSystem", security.ProtectSystem},
+		{"ProtectHome", security.ProtectHome},
+		{"PrivateTmp", security.PrivateTmp},
+		{"PrivateDevices", security.PrivateDevices},
+	} {
+		if item.value != "" {
+			result += fmt.Sprintf("%s=%s\n", item.name, item.value)
+		}
+	}
+
+	readWritePaths := security.ReadWritePaths
+
+	// Whole file system is read-only in strict mode, so log files must be
+	// writable for service
+	if security.ProtectSystem == "strict" {
+		readWritePaths = append(slices.Clone(readWritePaths), "-/var/log/"+sd.Application.Name)
+
+		if sd.Service.Options.IsCustomLogEnabled() {
+			readWritePaths = append(readWritePaths, "-"+path.Dir(sd.Service.Options.FullLogPath()))
+		}
+	}
+
+	for _, item := range []struct {
+		name   string
+		values []string
+	}{
+		{"ReadWritePaths", readWritePaths},
+		{"ReadOnlyPaths", security.ReadOnlyPaths},
+		{"CapabilityBoundingSet", security.CapabilityBoundingSet},
+		{"AmbientCapabilities", security.AmbientCapabilities},
+		{"SystemCallFilter", security.SystemCallFilter},
+		{"RestrictAddressFamilies", security.RestrictAddressFamilies},
+	} {
+		// Empty list is rendered, because it has meaning (i.e. empty
+		// CapabilityBoundingSet drops all capabilities)
+		if item.values != nil {
+			result += fmt.Sprintf("%s=%s\n", item.name, strings.Join(item.values, " "))
+		}
+	}
+
+	return result
+}
+
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 // CheckRequirements checks provider requirements for given application
@@ -263,7 +420,7 @@ func (sp *SystemdProvider) RenderAppTemplate(app *procfile.Application) (string,
 	data := &systemdAppData{
 		Application:  app,
 		ReloadHelper: app.ReloadHelperPath,
-		Wants:        sp.renderWantsClause(sp.getServiceList(app), app.Depends, app.StrongDependencies),
+		Wants:        sp.renderWantsClause(slices.Concat(sp.getTaskList(app), sp.getWantedList(app), sp.getTimerList(app)), app.Depends, app.StrongDependencies),
 		After:        sp.renderAfterClause(app.StartLevel, app.StartDevice, app.Depends),
 		StartLevel:   sp.renderLevel(app.StartLevel),
 		StopLevel:    sp.renderLevel(app.StopLevel),
@@ -276,18 +433,30 @@ func (sp *SystemdProvider) RenderAppTemplate(app *procfile.Application) (string,
 // RenderServiceTemplate renders unit template data with given service data and
 // return service unit code
 func (sp *SystemdProvider) RenderServiceTemplate(service *procfile.Service) (string, error) {
+	credentials, err := renderCredentials(service)
+
+	if err != nil {
+		return "", err
+	}
+
 	data := &systemdServiceData{
-		Application: service.Application,
-		Service:     service,
-		ExportDate:  timeutil.Format(time.Now(), "%Y/%m/%d %H:%M:%S"),
+		Application:  service.Application,
+		Service:      service,
+		ExportDate:   timeutil.Format(time.Now(), "%Y/%m/%d %H:%M:%S"),
+		Dependencies: sp.renderServiceDeps(service),
+		Credentials:  credentials,
 	}
 
 	return renderTemplate("systemd-service-template", TEMPLATE_SYSTEMD_SERVICE, data)
 }
 
 // RenderHelperTemplate renders helper template data with given service data and
-// return helper script code
+// return helper script code (empty if service doesn't require helper)
 func (sp *SystemdProvider) RenderHelperTemplate(service *procfile.Service) (string, error) {
+	if service.IsDirectExec() {
+		return "", nil
+	}
+
 	data := &systemdServiceData{
 		Application: service.Application,
 		Service:     service,
@@ -297,6 +466,93 @@ func (sp *SystemdProvider) RenderHelperTemplate(service *procfile.Service) (stri
 	return renderTemplate("systemd-helper-template", TEMPLATE_SYSTEMD_HELPER, data)
 }
 
+// RenderSocketTemplate renders socket unit for service with socket activation
+func (sp *SystemdProvider) RenderSocketTemplate(service *procfile.Service) (string, error) {
+	if !service.HasSockets() {
+		return "", nil
+	}
+
+	data := &systemdSocketData{
+		Application: service.Application,
+		Service:     service,
+		Unit:        sp.UnitName(getServiceUnits(service)[0]),
+		ExportDate:  timeutil.Format(time.Now(), "%Y/%m/%d %H:%M:%S"),
+	}
+
+	return renderTemplate("systemd-socket-template", TEMPLATE_SYSTEMD_SOCKET, data)
+}
+
+// RenderTaskTemplate renders oneshot unit for task, every task is started after
+// application unit (which prepares log directory) and previous task
+func (sp *SystemdProvider) RenderTaskTemplate(task *procfile.Task) (string, error) {
+	data := &systemdServiceData{
+		Application:  task.Application,
+		Service:      task.AsService(),
+		ExportDate:   timeutil.Format(time.Now(), "%Y/%m/%d %H:%M:%S"),
+		Dependencies: "After=" + sp.UnitName(task.Application.Name),
+	}
+
+	index := slices.Index(task.Application.Tasks, task)
+
+	if index > 0 {
+		prevUnit := sp.UnitName(task.Application.Name + "-" + task.Application.Tasks[index-1].Name)
+		data.Dependencies += " " + prevUnit + "\nRequires=" + prevUnit
+	}
+
+	return renderTemplate("systemd-task-template", TEMPLATE_SYSTEMD_TASK, data)
+}
+
+// RunTask starts task unit and waits until task is finished (unit remains active
+// after previous run, so it must be restarted)
+func (sp *SystemdProvider) RunTask(task *procfile.Task) error {
+	err := exec.Run("systemctl", "restart", sp.UnitName(task.Application.Name+"-"+task.Name))
+
+	if err != nil {
+		return fmt.Errorf("Task %s failed (see /var/log/%s/%s.log for details)", task.Name, task.Application.Name, task.Name)
+	}
+
+	return nil
+}
+
+// RenderScheduleTemplates renders service and timer units for scheduled job
+func (sp *SystemdProvider) RenderScheduleTemplates(schedule *procfile.Schedule) (map[string]string, error) {
+	name := schedule.Application.Name + "-" + schedule.Name
+
+	job, err := renderTemplate("systemd-job-template", TEMPLATE_SYSTEMD_JOB, &systemdServiceData{
+		Application: schedule.Application,
+		Service:     schedule.AsService(),
+		ExportDate:  timeutil.Format(time.Now(), "%Y/%m/%d %H:%M:%S"),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	timer, err := renderTemplate("systemd-timer-template", TEMPLATE_SYSTEMD_TIMER, &systemdTimerData{
+		Application: schedule.Application,
+		Schedule:    schedule,
+		Unit:        sp.UnitName(name),
+		ExportDate:  timeutil.Format(time.Now(), "%Y/%m/%d %H:%M:%S"),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{sp.UnitName(name): job, name + ".timer": timer}, nil
+}
+
+// RenderCronTemplate renders cron table with scheduled jobs (scheduled jobs
+// are executed by timers, so cron table is not required)
+func (sp *SystemdProvider) RenderCronTemplate(app *procfile.Application) (string, error) {
+	return "", nil
+}
+
+// RenderCheckerTemplate renders health checker script for given service
+func (sp *SystemdProvider) RenderCheckerTemplate(service *procfile.Service) (string, error) {
+	return renderHealthChecker(service, "systemd")
+}
+
 // RenderReloadHelperTemplate renders helper template data for reloading services
 func (sp *SystemdProvider) RenderReloadHelperTemplate(app *procfile.Application) (string, error) {
 	data := &systemdAppData{
@@ -319,6 +575,89 @@ func (sd *systemdServiceData) GetMemlockLimit() string {
 	return fmt.Sprintf("%d", sd.Service.Options.LimitMemlock)
 }
 
+// IsEnvironmentSet returns true if service has env vars or file with env vars
+// which are not exported by helper
+func (sd *systemdServiceData) IsEnvironmentSet() bool {
+	return sd.EnvironmentAsString() != ""
+}
+
+// EnvironmentAsString returns Environment and EnvironmentFile directives (env
+// vars which values require shell expansion are exported by helper)
+func (sd *systemdServiceData) EnvironmentAsString() string {
+	var result []string
+
+	options := sd.Service.Options
+
+	for _, name := range slices.Sorted(maps.Keys(options.Env)) {
+		if procfile.IsShellEnvValue(options.Env[name]) {
+			continue // Exported by helper
+		}
+
+		result = append(result, "Environment="+escapeSystemdValue(name+"="+unquoteEnvValue(options.Env[name])))
+	}
+
+	for _, file := range options.EnvFiles {
+		if file.Required {
+			result = append(result, "EnvironmentFile="+options.FullEnvFilePath(file))
+		} else {
+			result = append(result, "EnvironmentFile=-"+options.FullEnvFilePath(file))
+		}
+	}
+
+	return strings.Join(result, "\n")
+}
+
+// TypeOptions returns directives related to type of service
+func (sd *systemdServiceData) TypeOptions() string {
+	var result []string
+
+	options := sd.Service.Options
+
+	switch {
+	case options.NotifyAccess != "":
+		result = append(result, "NotifyAccess="+options.NotifyAccess)
+	case options.IsNotifyType() && !sd.Service.IsDirectExec():
+		// Command is started by helper, so notifications are sent by child
+		// process instead of main process
+		result = append(result, "NotifyAccess=all")
+	}
+
+	if options.WatchdogSec > 0 {
+		result = append(result, fmt.Sprintf("WatchdogSec=%d", options.WatchdogSec))
+	}
+
+	if options.PIDFile != "" {
+		result = append(result, "PIDFile="+options.PIDFile)
+	}
+
+	return strings.Join(result, "\n")
+}
+
+// DirectExec returns directives for executing service commands without helper
+func (sd *systemdServiceData) DirectExec() string {
+	var result []string
+
+	service := sd.Service
+	logFile := "/var/log/" + sd.Application.Name + "/" + service.Name + ".log"
+
+	if service.Options.IsCustomLogEnabled() {
+		logFile = service.Options.FullLogPath()
+	}
+
+	result = append(result,
+		"StandardOutput=append:"+logFile,
+		"StandardError=append:"+logFile,
+	)
+
+	if service.HasPreCmd() {
+		result = append(result, "ExecStartPre="+renderSystemdCommand(service.PreCmd, service.PreCmdArgs))
+	}
+
+	result = append(result, "ExecStart="+renderSystemdCommand(service.Cmd, service.CmdArgs))
+
+	return strings.Join(result, "\n")
+}
+
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 // renderLevel converts level number to systemd level name
@@ -370,23 +709,146 @@ func (sp *SystemdProvider) renderAfterClause(level int, device string, deps []st
 	return strings.Join(after, " ")
 }
 
+// renderServiceDeps renders dependencies on tasks and other services of
+// application, services with lower priority are started before service
+func (sp *SystemdProvider) renderServiceDeps(service *procfile.Service) string {
+	var after, requires []string
+
+	// All services are started after tasks of application
+	requires = append(requires, sp.getTaskList(service.Application)...)
+
+	// Service started by socket requires its socket
+	if service.HasSockets() && !service.Sockets.Accept {
+		requires = append(requires, sp.getActivationUnits(service)...)
+	}
+
+	for _, dep := range getDependencyServices(service) {
+		requires = append(requires, sp.getActivationUnits(dep)...)
+	}
+
+	after = slices.Clone(requires)
+
+	for _, s := range getPriorityServices(service) {
+		for _, unit := range sp.getActivationUnits(s) {
+			if !slices.Contains(after, unit) {
+				after = append(after, unit)
+			}
+		}
+	}
+
+	var result []string
+
+	if len(after) != 0 {
+		result = append(result, "After="+strings.Join(after, " "))
+	}
+
+	if len(requires) != 0 {
+		result = append(result, "Requires="+strings.Join(requires, " "))
+	}
+
+	return strings.Join(result, "\n")
+}
+
+// renderCredentials renders credentials of service, plain secrets are loaded by
+// systemd from files on start and encrypted secrets are embedded into unit
+// (they can be decrypted only on this host)
+func renderCredentials(service *procfile.Service) (string, error) {
+	var result string
+
+	for _, secret := range service.Options.Secrets {
+		if !secret.Encrypted {
+			result += fmt.Sprintf("LoadCredential=%s:%s\n", secret.Name, secret.File)
+			continue
+		}
+
+		data, err := os.ReadFile(secret.File)
+
+		if err != nil {
+			return "", fmt.Errorf("Can't read encrypted secret %s of service %s: %v", secret.Name, service.Name, err)
+		}
+
+		// Credentials are encrypted by systemd-creds in Base64 format
+		value := strings.Join(strings.Fields(string(data)), "")
+		_, err = base64.StdEncoding.DecodeString(value)
+
+		if value == "" || err != nil {
+			return "", fmt.Errorf("File %s doesn't contain credential encrypted by systemd-creds (secret %s of service %s)", secret.File, secret.Name, service.Name)
+		}
+
+		result += fmt.Sprintf("SetCredentialEncrypted=%s:%s\n", secret.Name, value)
+	}
+
+	return result, nil
+}
+
 // getServiceList return slice with all child services
 func (sp *SystemdProvider) getServiceList(app *procfile.Application) []string {
 	var result []string
 
 	for _, service := range app.Services {
-		if service.Options.Count <= 0 {
-			result = append(result, sp.UnitName(app.Name+"-"+service.Name))
+		// Instances of service started for every connection have unknown names
+		if service.HasSockets() && service.Sockets.Accept {
+			result = append(result, getServiceUnits(service)[0]+"@*.service")
+			continue
+		}
+
+		for _, unit := range getServiceUnits(service) {
+			result = append(result, sp.UnitName(unit))
+		}
+	}
+
+	return result
+}
+
+// getWantedList returns slice with units which must be started with application
+func (sp *SystemdProvider) getWantedList(app *procfile.Application) []string {
+	var result []string
+
+	for _, service := range app.Services {
+		result = append(result, sp.getActivationUnits(service)...)
+	}
+
+	return result
+}
+
+// getActivationUnits returns units which start given service (socket for
+// services with socket activation and service units for others)
+func (sp *SystemdProvider) getActivationUnits(service *procfile.Service) []string {
+	var result []string
+
+	for _, unit := range getServiceUnits(service) {
+		if service.HasSockets() {
+			result = append(result, unit+".socket")
 		} else {
-			for i := 1; i <= service.Options.Count; i++ {
-				result = append(result, sp.UnitName(app.Name+"-"+service.Name+strconv.Itoa(i)))
-			}
+			result = append(result, sp.UnitName(unit))
 		}
 	}
 
 	return result
 }
 
+// getTaskList returns list of units of tasks
+func (sp *SystemdProvider) getTaskList(app *procfile.Application) []string {
+	var result []string
+
+	for _, task := range app.Tasks {
+		result = append(result, sp.UnitName(app.Name+"-"+task.Name))
+	}
+
+	return result
+}
+
+// getTimerList returns list of timers of scheduled jobs
+func (sp *SystemdProvider) getTimerList(app *procfile.Application) []string {
+	var result []string
+
+	for _, schedule := range app.Schedules {
+		result = append(result, app.Name+"-"+schedule.Name+".timer")
+	}
+
+	return result
+}
+
 // depsToServiceList converts dependencies to services list
 func (sp *SystemdProvider) depsToServiceList(deps []string) []string {
 	var result []string
@@ -414,3 +876,41 @@ func getDepOption(strongDeps bool) string {
 		return "Wants="
 	}
 }
+
+// renderSystemdCommand renders command line for Exec* directives, commands
+// defined as string are executed by bash
+func renderSystemdCommand(cmd string, args []string) string {
+	if args == nil {
+		args = []string{"/bin/bash", "-c", cmd}
+	}
+
+	result := make([]string, len(args))
+
+	for index, arg := range args {
+		result[index] = escapeSystemdArg(arg)
+	}
+
+	return strings.Join(result, " ")
+}
+
+// escapeSystemdArg escapes and quotes argument of systemd command line
+func escapeSystemdArg(arg string) string {
+	if arg == ";" {
+		return `\;`
+	}
+
+	return escapeSystemdValue(strings.ReplaceAll(arg, "$", "$$"))
+}
+
+// escapeSystemdValue escapes and quotes value of systemd directive
+func escapeSystemdValue(value string) string {
+	value = strings.NewReplacer(
+		`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "%", "%%",
+	).Replace(value)
+
+	if value != "" && !strings.ContainsAny(value, " '") && !strings.HasSuffix(value, ";") {
+		return value
+	}
+
+	return `"` + value + `"`
+}
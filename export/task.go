@@ -0,0 +1,33 @@
+package export
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                           Copyright (c) 2006-2024 FUNBOX                           //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+
+	"github.com/funbox/init-exporter/procfile"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// renderTaskExec renders shell command for executing task helper by root
+// on behalf of task user (env vars are set by helper, so they are not visible
+// in process list)
+func renderTaskExec(task *procfile.Task) string {
+	return fmt.Sprintf(
+		"sudo -u %s /bin/bash %s &>>/var/log/%s/%s.log",
+		task.GetUser(), task.HelperPath, task.Application.Name, task.Name,
+	)
+}
+
+// isStandaloneJob returns true if given service is task or scheduled job, which
+// are executed outside of service units
+func isStandaloneJob(service *procfile.Service) bool {
+	app := service.Application
+
+	return app != nil && (app.GetTask(service.Name) != nil || app.GetSchedule(service.Name) != nil)
+}
@@ -8,7 +8,12 @@ package export
 
 import (
 	"fmt"
+	"maps"
+	"os"
 	"os/exec"
+	"path"
+	"regexp"
+	"slices"
 	"strings"
 	"time"
 
@@ -33,7 +38,11 @@ const TEMPLATE_UPSTART_HELPER = `#!/bin/bash
 
 [[ -r /etc/profile.d/rbenv.sh ]] && source /etc/profile.d/rbenv.sh
 [[ -r /etc/profile.d/pyenv.sh ]] && source /etc/profile.d/pyenv.sh
-
+{{ if .Service.Options.IsEnvFileSet }}
+{{.EnvFilesAsString}}
+{{ end }}{{ if .HelperEnvAsString }}
+{{.HelperEnvAsString}}
+{{ end }}
 cd {{.Service.Options.WorkingDir}} && {{ if .Service.HasPreCmd }}{{.Service.GetCommandExec "pre"}} && {{ end }}{{.Service.GetCommandExec ""}}{{ if .Service.HasPostCmd }} && {{.Service.GetCommandExec "post"}}{{ end }}
 `
 
@@ -47,10 +56,11 @@ pre-start script
 
 bash << "EOF"
   mkdir -p /var/log/{{.Application.Name}}
-  chown -R {{.Application.User}} /var/log/{{.Application.Name}}
-  chgrp -R {{.Application.Group}} /var/log/{{.Application.Name}}
-  chmod -R g+w /var/log/{{.Application.Name}}
-EOF
+  chown {{.Application.User}} /var/log/{{.Application.Name}}
+  chgrp {{.Application.Group}} /var/log/{{.Application.Name}}
+  chmod g+w /var/log/{{.Application.Name}}
+{{ range .Tasks }}  {{.}} || exit 1
+{{ end }}EOF
 
 end script
 `
@@ -73,14 +83,29 @@ kill timeout {{.Service.Options.KillTimeout}}
 {{ if .Service.Options.IsProcLimitSet }}limit nproc {{.Service.Options.LimitProc}} {{.Service.Options.LimitProc}}{{ end }}
 {{ if .Service.Options.IsMemlockLimitSet }}limit memlock {{.GetMemlockLimit}} {{.GetMemlockLimit}}{{ end }}
 
-script
+{{ if .IsEnvironmentSet }}{{.EnvironmentAsString}}
+
+{{ end }}script
   touch /var/log/{{.Application.Name}}/{{.Service.Name}}.log
-  chown {{.Application.User}} /var/log/{{.Application.Name}}/{{.Service.Name}}.log
-  chgrp {{.Application.Group}} /var/log/{{.Application.Name}}/{{.Service.Name}}.log
+  chown {{.Service.GetUser}} /var/log/{{.Application.Name}}/{{.Service.Name}}.log
+  chgrp {{.Service.GetGroup}} /var/log/{{.Application.Name}}/{{.Service.Name}}.log
   chmod g+w /var/log/{{.Application.Name}}/{{.Service.Name}}.log
-  exec sudo -u {{.Application.User}} /bin/bash {{.Service.HelperPath}} &>>/var/log/{{.Application.Name}}/{{.Service.Name}}.log
+{{ if .Service.Options.IsSecretsSet }}{{.SecretsAsString}}
+{{ end }}  exec sudo {{ if .IsEnvironmentSet }}-E {{ end }}-u {{.Service.GetUser}} {{ if .Service.Options.IsSecretsSet }}CREDENTIALS_DIRECTORY={{.CredentialsDir}} {{ end }}/bin/bash {{.Service.HelperPath}} &>>/var/log/{{.Application.Name}}/{{.Service.Name}}.log
 end script
-`
+{{ if .Service.HasHealthCheck }}
+post-start script
+  exec sudo -u {{.Service.GetUser}} /bin/bash {{.Service.CheckerPath}} &>>/var/log/{{.Application.Name}}/{{.Service.Name}}.log
+end script
+{{ end }}`
+
+// TEMPLATE_UPSTART_CRON contains default cron table template
+const TEMPLATE_UPSTART_CRON = `# This file generated {{.ExportDate}} by init-exporter/upstart for {{.Application.Name}} application
+
+SHELL=/bin/bash
+
+{{ range .Jobs }}{{.}}
+{{ end }}`
 
 // ////////////////////////////////////////////////////////////////////////////////// //
 
@@ -89,14 +114,22 @@ type upstartAppData struct {
 	ExportDate  string
 	StartLevel  string
 	StopLevel   string
+	Tasks       []string
 }
 
-type upstartServiceData struct {
+type upstartCronData struct {
 	Application *procfile.Application
-	Service     *procfile.Service
 	ExportDate  string
-	StartLevel  string
-	StopLevel   string
+	Jobs        []string
+}
+
+type upstartServiceData struct {
+	Application  *procfile.Application
+	Service      *procfile.Service
+	ExportDate   string
+	StartLevel   string
+	StopLevel    string
+	IsStandalone bool // Task or scheduled job executed outside of upstart jobs
 }
 
 // ////////////////////////////////////////////////////////////////////////////////// //
@@ -114,7 +147,31 @@ func NewUpstart() *UpstartProvider {
 
 // CheckRequirements checks provider requirements for given application
 func (up *UpstartProvider) CheckRequirements(app *procfile.Application) error {
-	return checkReloadSignalSupport(app)
+	err := checkReloadSignalSupport(app)
+
+	if err != nil {
+		return err
+	}
+
+	for _, service := range app.Services {
+		if service.HasSockets() {
+			return fmt.Errorf("Socket activation is not supported by upstart (service %s)", service.Name)
+		}
+
+		if service.Options.HasEncryptedSecrets() {
+			return fmt.Errorf("Encrypted secrets are not supported by upstart (service %s)", service.Name)
+		}
+	}
+
+	for _, schedule := range app.Schedules {
+		_, err = getCronSpec(schedule)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // UnitName returns unit name with extension
@@ -147,6 +204,10 @@ func (up *UpstartProvider) RenderAppTemplate(app *procfile.Application) (string,
 		ExportDate:  timeutil.Format(time.Now(), "%Y/%m/%d %H:%M:%S"),
 	}
 
+	for _, task := range app.Tasks {
+		data.Tasks = append(data.Tasks, renderTaskExec(task))
+	}
+
 	return renderTemplate("upstart-app-template", TEMPLATE_UPSTART_APP, data)
 }
 
@@ -156,7 +217,7 @@ func (up *UpstartProvider) RenderServiceTemplate(service *procfile.Service) (str
 	data := &upstartServiceData{
 		Application: service.Application,
 		Service:     service,
-		StartLevel:  fmt.Sprintf("starting %s", service.Application.Name),
+		StartLevel:  up.renderServiceStartLevel(service),
 		StopLevel:   fmt.Sprintf("stopping %s", service.Application.Name),
 		ExportDate:  timeutil.Format(time.Now(), "%Y/%m/%d %H:%M:%S"),
 	}
@@ -168,14 +229,79 @@ func (up *UpstartProvider) RenderServiceTemplate(service *procfile.Service) (str
 // return helper script code
 func (up *UpstartProvider) RenderHelperTemplate(service *procfile.Service) (string, error) {
 	data := &upstartServiceData{
-		Application: service.Application,
-		Service:     service,
-		ExportDate:  timeutil.Format(time.Now(), "%Y/%m/%d %H:%M:%S"),
+		Application:  service.Application,
+		Service:      service,
+		ExportDate:   timeutil.Format(time.Now(), "%Y/%m/%d %H:%M:%S"),
+		IsStandalone: isStandaloneJob(service),
 	}
 
 	return renderTemplate("upstart-helper-template", TEMPLATE_UPSTART_HELPER, data)
 }
 
+// RenderScheduleTemplates renders units for scheduled job (scheduled jobs are
+// executed by cron, so units are not required)
+func (up *UpstartProvider) RenderScheduleTemplates(schedule *procfile.Schedule) (map[string]string, error) {
+	return nil, nil
+}
+
+// RenderCronTemplate renders cron table with scheduled jobs of application
+func (up *UpstartProvider) RenderCronTemplate(app *procfile.Application) (string, error) {
+	if len(app.Schedules) == 0 {
+		return "", nil
+	}
+
+	data := &upstartCronData{
+		Application: app,
+		ExportDate:  timeutil.Format(time.Now(), "%Y/%m/%d %H:%M:%S"),
+	}
+
+	for _, schedule := range app.Schedules {
+		job, err := renderCronJob(schedule)
+
+		if err != nil {
+			return "", err
+		}
+
+		data.Jobs = append(data.Jobs, job)
+	}
+
+	return renderTemplate("upstart-cron-template", TEMPLATE_UPSTART_CRON, data)
+}
+
+// RenderTaskTemplate renders unit for task (tasks are executed in pre-start
+// script of application, so units are not required)
+func (up *UpstartProvider) RenderTaskTemplate(task *procfile.Task) (string, error) {
+	return "", nil
+}
+
+// RunTask executes task helper and waits until task is finished
+func (up *UpstartProvider) RunTask(task *procfile.Task) error {
+	err := os.MkdirAll("/var/log/"+task.Application.Name, 0755)
+
+	if err != nil {
+		return err
+	}
+
+	err = exec.Command("/bin/bash", "-c", renderTaskExec(task)).Run()
+
+	if err != nil {
+		return fmt.Errorf("Task %s failed (see /var/log/%s/%s.log for details)", task.Name, task.Application.Name, task.Name)
+	}
+
+	return nil
+}
+
+// RenderSocketTemplate renders socket unit for service (socket activation is
+// not supported by upstart)
+func (up *UpstartProvider) RenderSocketTemplate(service *procfile.Service) (string, error) {
+	return "", nil
+}
+
+// RenderCheckerTemplate renders health checker script for given service
+func (up *UpstartProvider) RenderCheckerTemplate(service *procfile.Service) (string, error) {
+	return renderHealthChecker(service, "upstart")
+}
+
 // RenderReloadHelperTemplate renders helper template data for reloading services
 func (up *UpstartProvider) RenderReloadHelperTemplate(app *procfile.Application) (string, error) {
 	return "", nil
@@ -202,6 +328,36 @@ func (up *UpstartProvider) renderStartLevel(level int, device string, deps []str
 	return strings.Join(depsList, " and ")
 }
 
+// renderServiceStartLevel renders start event of service, services with
+// dependencies are started after all dependencies and services with lower
+// priority, services of application with tasks are started after pre-start
+// script of application
+func (up *UpstartProvider) renderServiceStartLevel(service *procfile.Service) string {
+	units := getDependencyUnits(service)
+
+	for _, unit := range getPriorityUnits(service) {
+		if !slices.Contains(units, unit) {
+			units = append(units, unit)
+		}
+	}
+
+	if len(units) == 0 && service.Application.HasTasks() {
+		return fmt.Sprintf("started %s", service.Application.Name)
+	}
+
+	if len(units) == 0 {
+		return fmt.Sprintf("starting %s", service.Application.Name)
+	}
+
+	var depsList []string
+
+	for _, unit := range units {
+		depsList = append(depsList, "started "+unit)
+	}
+
+	return strings.Join(depsList, " and ")
+}
+
 // renderStopLevel converts level number to upstart stop level name
 func (up *UpstartProvider) renderStopLevel(level int, deps []string) string {
 	if len(deps) == 0 {
@@ -228,8 +384,101 @@ func (d *upstartServiceData) GetMemlockLimit() string {
 	return fmt.Sprintf("%d", d.Service.Options.LimitMemlock)
 }
 
+// IsEnvironmentSet returns true if service has env vars which are not exported
+// by helper
+func (d *upstartServiceData) IsEnvironmentSet() bool {
+	return d.EnvironmentAsString() != ""
+}
+
+// EnvironmentAsString returns env stanzas for env vars which values don't
+// require shell expansion
+func (d *upstartServiceData) EnvironmentAsString() string {
+	var result []string
+
+	for _, name := range slices.Sorted(maps.Keys(d.Service.Options.Env)) {
+		if procfile.IsShellEnvValue(d.Service.Options.Env[name]) {
+			continue // Exported by helper
+		}
+
+		result = append(result, "env "+name+"="+escapeUpstartValue(unquoteEnvValue(d.Service.Options.Env[name])))
+	}
+
+	return strings.Join(result, "\n")
+}
+
+// HelperEnvAsString returns export statements for env vars which are set by
+// helper (env vars of tasks and scheduled jobs and env vars which values must
+// be expanded by shell)
+func (d *upstartServiceData) HelperEnvAsString() string {
+	var result []string
+
+	env := d.Service.Options.Env
+
+	for _, name := range slices.Sorted(maps.Keys(env)) {
+		switch {
+		case procfile.IsShellEnvValue(env[name]):
+			result = append(result, "export "+name+"="+env[name])
+		case d.IsStandalone:
+			result = append(result, "export "+name+"="+procfile.QuoteArg(unquoteEnvValue(env[name])))
+		}
+	}
+
+	return strings.Join(result, "\n")
+}
+
+// CredentialsDir returns path to runtime directory with copies of secret files
+func (d *upstartServiceData) CredentialsDir() string {
+	return path.Join("/run/credentials", strings.TrimSuffix(path.Base(d.Service.HelperPath), ".sh"))
+}
+
+// SecretsAsString returns commands for copying secret files to runtime
+// directory readable only by service user
+func (d *upstartServiceData) SecretsAsString() string {
+	dir := d.CredentialsDir()
+	owner := "-o " + d.Service.GetUser() + " -g " + d.Service.GetGroup()
+
+	result := []string{
+		"  rm -rf " + dir,
+		"  install -d -m 0500 " + owner + " " + dir,
+	}
+
+	for _, secret := range d.Service.Options.Secrets {
+		result = append(result, "  install -m 0400 "+owner+" "+secret.File+" "+path.Join(dir, secret.Name)+" || exit 1")
+	}
+
+	return strings.Join(result, "\n")
+}
+
+// EnvFilesAsString returns commands for loading env files in helper
+func (d *upstartServiceData) EnvFilesAsString() string {
+	var result []string
+
+	options := d.Service.Options
+
+	for _, file := range options.EnvFiles {
+		path := options.FullEnvFilePath(file)
+
+		if file.Required {
+			result = append(result, "set -a && source "+path+" && set +a || exit 1")
+		} else {
+			result = append(result, "[[ -r "+path+" ]] && set -a && source "+path+" && set +a")
+		}
+	}
+
+	return strings.Join(result, "\n")
+}
+
 // ////////////////////////////////////////////////////////////////////////////////// //
 
+// escapeUpstartValue escapes and quotes value of env stanza
+func escapeUpstartValue(value string) string {
+	if value != "" && regexp.MustCompile(procfile.REGEXP_SAFE_ARG).MatchString(value) {
+		return value
+	}
+
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value) + `"`
+}
+
 // checkReloadSignalSupport checks if app requires reload signal
 // and if current upstart version supports it
 func checkReloadSignalSupport(app *procfile.Application) error {
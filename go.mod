@@ -6,6 +6,7 @@ require (
 	github.com/essentialkaos/check v1.4.1
 	github.com/essentialkaos/ek/v13 v13.19.0
 	github.com/essentialkaos/go-simpleyaml/v2 v2.1.5
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
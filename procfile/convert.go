@@ -0,0 +1,217 @@
+package procfile
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                           Copyright (c) 2006-2024 FUNBOX                           //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/essentialkaos/ek/v13/fsutil"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// ConvertV1 converts procfile v1 to procfile v2 format and returns data of
+// converted procfile and warnings about parts which can't be represented in v2
+func ConvertV1(path string) ([]byte, []*Warning, error) {
+	err := fsutil.ValidatePerms("FRS", path)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if determineProcVersion(data) != 1 {
+		return nil, nil, fmt.Errorf("Procfile %s already has version 2 format", path)
+	}
+
+	app, err := parseV1Procfile(data, &Config{})
+
+	if err != nil {
+		e, ok := err.(*Error)
+
+		if ok {
+			e.Pos.File = path
+		}
+
+		return nil, nil, err
+	}
+
+	var warnings []*Warning
+
+	comments := extractV1Comments(data)
+	root, commands := newMapNode(), newMapNode()
+
+	addScalar(root, "version", "!!int", "2")
+	commandsKey := addNode(root, "commands", commands)
+
+	for _, service := range app.Services {
+		pos, _ := app.source.find("commands." + service.Name)
+		pos.File = path
+
+		key := addNode(commands, service.Name, convertV1Service(service))
+		key.HeadComment = comments[pos.Line]
+
+		for _, msg := range checkV1Command(strings.SplitN(getV1Line(data, pos.Line), ":", 2)[1]) {
+			warnings = append(warnings, &Warning{
+				Pos:      pos,
+				Severity: SEVERITY_WARNING,
+				Rule:     RULE_UNSUPPORTED,
+				Service:  service.Name,
+				Path:     "commands." + service.Name,
+				Message:  msg,
+			})
+		}
+	}
+
+	if comments[-1] != "" {
+		if len(app.Services) == 0 {
+			commandsKey.HeadComment = comments[-1]
+		} else {
+			commands.Content[len(commands.Content)-1].FootComment = comments[-1]
+		}
+	}
+
+	result, err := encodeDocument(root)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("Can't encode procfile: %v", err)
+	}
+
+	return result, warnings, nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// convertV1Service converts v1 service to v2 command node
+func convertV1Service(service *Service) *yaml.Node {
+	node := newMapNode()
+
+	addScalar(node, "command", "!!str", service.Cmd)
+
+	if service.HasPreCmd() {
+		addScalar(node, "pre", "!!str", service.PreCmd)
+	}
+
+	if service.HasPostCmd() {
+		addScalar(node, "post", "!!str", service.PostCmd)
+	}
+
+	if service.Options.WorkingDir != "" {
+		addScalar(node, "working_directory", "!!str", service.Options.WorkingDir)
+	}
+
+	if service.Options.IsCustomLogEnabled() {
+		addScalar(node, "log", "!!str", service.Options.LogFile)
+	}
+
+	if service.Options.IsEnvSet() {
+		env := newMapNode()
+
+		for _, name := range slices.Sorted(maps.Keys(service.Options.Env)) {
+			addScalar(env, name, "!!str", service.Options.Env[name])
+		}
+
+		addNode(node, "env", env)
+	}
+
+	return node
+}
+
+// checkV1Command checks v1 command for parts which are ignored by parser
+func checkV1Command(command string) []string {
+	var result []string
+
+	cmdSlice, err := splitV1Command(strings.TrimSpace(command))
+
+	if err != nil {
+		return nil
+	}
+
+	tokens, _ := Tokenize(cmdSlice[0])
+
+	if tokens[0].IsWord("cd") && len(cmdSlice) > 1 {
+		cmdSlice = cmdSlice[1:]
+	}
+
+	mainIndex := 0
+
+	switch len(cmdSlice) {
+	case 2, 3:
+		mainIndex = 1
+	case 1:
+		// only main command
+	default:
+		result = append(result, fmt.Sprintf(
+			"only pre, main and post commands are supported, all commands after %q are ignored",
+			cmdSlice[0],
+		))
+
+		cmdSlice = cmdSlice[:1]
+	}
+
+	for index, cmd := range cmdSlice {
+		_, log, env, _ := parseCommand(cmd)
+
+		if index != mainIndex && (log != "" || len(env) != 0) {
+			result = append(result, fmt.Sprintf(
+				"environment variables and log redirection in %q are ignored", cmd,
+			))
+		}
+	}
+
+	return result
+}
+
+// extractV1Comments returns comments from v1 procfile grouped by number of
+// line with command which follows them (-1 for comments at the end of file)
+func extractV1Comments(data []byte) map[int]string {
+	var comments []string
+
+	result := make(map[int]string)
+
+	for index, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "#"):
+			comments = append(comments, line)
+		case len(comments) != 0:
+			result[index+1] = strings.Join(comments, "\n")
+			comments = nil
+		}
+	}
+
+	if len(comments) != 0 {
+		result[-1] = strings.Join(comments, "\n")
+	}
+
+	return result
+}
+
+// getV1Line returns line with given number from procfile data
+func getV1Line(data []byte, line int) string {
+	lines := strings.Split(string(data), "\n")
+
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+
+	return strings.TrimSpace(lines[line-1])
+}
@@ -0,0 +1,309 @@
+package procfile
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                           Copyright (c) 2006-2024 FUNBOX                           //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/essentialkaos/ek/v13/fsutil"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// EnvFile contains info about file with environment variables
+type EnvFile struct {
+	Path     string // Path to file
+	Required bool   // File must exist
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// dotenvParser contains state of dotenv data parser
+type dotenvParser struct {
+	data  string
+	pos   int
+	line  int
+	lines map[string]int
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// ReadEnvFile reads and parses file with environment variables
+func ReadEnvFile(file string) (map[string]string, error) {
+	data, err := os.ReadFile(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	env, err := ParseEnv(data)
+
+	if err != nil {
+		err.(*Error).Pos.File = file
+	}
+
+	return env, err
+}
+
+// ParseEnv parses data in dotenv format (NAME=value lines with optional
+// export prefix, quoted values and comments)
+func ParseEnv(data []byte) (map[string]string, error) {
+	p := &dotenvParser{data: string(data), line: 1, lines: make(map[string]int)}
+	result := make(map[string]string)
+
+	for p.pos < len(p.data) {
+		name, value, ok, err := p.readVar()
+
+		if err != nil {
+			return nil, &Error{Pos: Position{Line: p.line}, Rule: RULE_INVALID_ENV, Message: err.Error()}
+		}
+
+		if ok {
+			result[name] = value
+		}
+	}
+
+	return result, nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// ValidateEnvFiles checks that all required env files exist and all env
+// files have valid format
+func (a *Application) ValidateEnvFiles() []error {
+	var errs []error
+
+	for _, service := range a.Services {
+		serviceErrs := validateEnvFiles(service.Options, service.Name)
+		a.source.annotate(service.Name, serviceErrs...)
+		errs = append(errs, serviceErrs...)
+	}
+
+	for _, schedule := range a.Schedules {
+		scheduleErrs := validateEnvFiles(schedule.Options, "")
+		schedule.annotate(scheduleErrs...)
+		errs = append(errs, scheduleErrs...)
+	}
+
+	for _, task := range a.Tasks {
+		taskErrs := validateEnvFiles(task.Options, "")
+		task.annotate(taskErrs...)
+		errs = append(errs, taskErrs...)
+	}
+
+	return errs
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// validateEnvFiles checks env files from given options
+func validateEnvFiles(options *ServiceOptions, service string) []error {
+	var errs []error
+
+	for _, file := range options.EnvFiles {
+		path := options.FullEnvFilePath(file)
+
+		if !fsutil.IsExist(path) {
+			if file.Required {
+				errs = append(errs, &Error{
+					Rule:    RULE_INVALID_ENV,
+					Path:    "env_file",
+					Message: fmt.Sprintf("File %s doesn't exist", path),
+				})
+			}
+
+			continue
+		}
+
+		_, err := ReadEnvFile(path)
+
+		switch e := err.(type) {
+		case nil:
+			// ok
+		case *Error:
+			// Error already has position in env file
+			e.Service = service
+			errs = append(errs, e)
+		default:
+			errs = append(errs, newError(RULE_INVALID_ENV, "env_file", err))
+		}
+	}
+
+	return errs
+}
+
+// readVar reads next line with variable (ok is false for empty lines and comments),
+// only syntax which is read in the same way by bash and systemd is accepted
+func (p *dotenvParser) readVar() (string, string, bool, error) {
+	p.skipBlank()
+
+	if p.pos == len(p.data) || p.peek() == '\n' || p.peek() == '#' {
+		p.skipLine()
+		return "", "", false, nil
+	}
+
+	line := p.readUntil("=\n")
+
+	if p.pos == len(p.data) || p.peek() != '=' {
+		return "", "", false, fmt.Errorf("Line must have NAME=value format")
+	}
+
+	p.pos++
+
+	name := strings.TrimSpace(line)
+
+	if strings.HasPrefix(name, "export ") || strings.HasPrefix(name, "export\t") {
+		name = strings.TrimSpace(name[len("export"):])
+	}
+
+	if !regexp.MustCompile(REGEXP_NAME_CHECK).MatchString(name) {
+		return "", "", false, fmt.Errorf("Environment variable name %s is misformatted and can't be accepted", name)
+	}
+
+	if line, ok := p.lines[name]; ok {
+		return "", "", false, fmt.Errorf("Environment variable %s is already defined on line %d", name, line)
+	}
+
+	p.lines[name] = p.line
+
+	// Bash treats spaces around = as command separator
+	if strings.TrimRight(line, " \t") != line || (p.pos < len(p.data) && (p.peek() == ' ' || p.peek() == '\t')) {
+		p.skipBlank()
+
+		if p.pos < len(p.data) && p.peek() != '\n' {
+			return "", "", false, fmt.Errorf("Environment variable %s can't have spaces around =", name)
+		}
+	}
+
+	var value string
+	var err error
+
+	switch {
+	case p.pos == len(p.data):
+		return name, "", true, nil
+	case p.peek() == '\'':
+		value, err = p.readQuoted('\'', name)
+	case p.peek() == '"':
+		value, err = p.readQuoted('"', name)
+	default:
+		value, err = p.readUnquoted(name)
+	}
+
+	if err != nil {
+		return "", "", false, err
+	}
+
+	p.skipBlank()
+
+	// Comments after value are kept as part of value by systemd
+	if p.pos < len(p.data) && p.peek() != '\n' {
+		return "", "", false, fmt.Errorf("Unexpected data after value of environment variable %s", name)
+	}
+
+	p.skipLine()
+
+	return name, value, true, nil
+}
+
+// readQuoted reads quoted value (value can contain new lines), double quoted
+// value can't contain command substitution and variables
+func (p *dotenvParser) readQuoted(quote byte, name string) (string, error) {
+	var value strings.Builder
+
+	start := p.line
+	p.pos++
+
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		p.pos++
+
+		switch {
+		case c == quote:
+			return value.String(), nil
+
+		case c == '\\' && quote == '"' && p.pos < len(p.data):
+			c = p.data[p.pos]
+			p.pos++
+
+			switch c {
+			case '"', '\\', '$', '`':
+				value.WriteByte(c)
+			case '\n':
+				p.line++
+			default:
+				value.WriteByte('\\')
+				value.WriteByte(c)
+			}
+
+		case quote == '"' && (c == '$' || c == '`'):
+			return "", fmt.Errorf("Value of environment variable %s contains unescaped %q (use single quotes for literal value)", name, c)
+
+		default:
+			if c == '\n' {
+				p.line++
+			}
+
+			value.WriteByte(c)
+		}
+	}
+
+	p.line = start
+
+	return "", fmt.Errorf("Unterminated quoted value")
+}
+
+// readUnquoted reads unquoted value until the end of line, value can't contain
+// spaces and shell special characters
+func (p *dotenvParser) readUnquoted(name string) (string, error) {
+	value := strings.TrimRight(p.readUntil("\n"), " \t\r")
+
+	if strings.ContainsAny(value, " \t") {
+		return "", fmt.Errorf("Value of environment variable %s contains spaces and must be quoted", name)
+	}
+
+	if strings.ContainsAny(value, "$`'\"\\;&|<>()") {
+		return "", fmt.Errorf("Value of environment variable %s contains shell special characters and must be quoted with single quotes", name)
+	}
+
+	return value, nil
+}
+
+// readUntil reads data until one of given characters
+func (p *dotenvParser) readUntil(chars string) string {
+	start := p.pos
+
+	for p.pos < len(p.data) && strings.IndexByte(chars, p.peek()) == -1 {
+		p.pos++
+	}
+
+	return p.data[start:p.pos]
+}
+
+// skipBlank skips spaces and tabs
+func (p *dotenvParser) skipBlank() {
+	for p.pos < len(p.data) && (p.peek() == ' ' || p.peek() == '\t' || p.peek() == '\r') {
+		p.pos++
+	}
+}
+
+// skipLine skips data until the start of next line
+func (p *dotenvParser) skipLine() {
+	p.readUntil("\n")
+
+	if p.pos < len(p.data) {
+		p.pos++
+		p.line++
+	}
+}
+
+// peek returns current character
+func (p *dotenvParser) peek() byte {
+	return p.data[p.pos]
+}
@@ -0,0 +1,100 @@
+package procfile
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                           Copyright (c) 2006-2024 FUNBOX                           //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"bytes"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// encodeDocument encodes YAML document and separates top-level sections
+// and commands with empty lines
+func encodeDocument(node *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+
+	if node.Kind != yaml.DocumentNode {
+		node = &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{node}}
+	}
+
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+
+	err := enc.Encode(node)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = enc.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+	var isCommands bool
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+
+	for index, line := range lines {
+		if index != 0 && needEmptyLine(lines[index-1], line, isCommands) {
+			result = append(result, "")
+		}
+
+		if line != "" && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "#") {
+			isCommands = line == "commands:"
+		}
+
+		result = append(result, line)
+	}
+
+	return []byte(strings.Join(result, "\n") + "\n"), nil
+}
+
+// needEmptyLine returns true if empty line must be added between given lines
+func needEmptyLine(prev, line string, isCommands bool) bool {
+	if prev == "" || line == "" {
+		return false
+	}
+
+	// Commands are always separated from each other
+	if isCommands && len(line) > 2 && strings.HasPrefix(line, "  ") && line[2] != ' ' {
+		return prev != "commands:" && !strings.HasPrefix(prev, "  #")
+	}
+
+	if strings.HasPrefix(line, " ") || strings.HasPrefix(prev, "#") {
+		return false
+	}
+
+	// Top-level sections and comments are separated from other properties,
+	// simple properties are grouped together
+	return strings.HasPrefix(prev, " ") || strings.HasPrefix(prev, "version:") ||
+		strings.HasPrefix(line, "#") || strings.HasSuffix(line, ":")
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// newMapNode creates new mapping node
+func newMapNode() *yaml.Node {
+	return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+}
+
+// addNode adds value node with given key to mapping node
+func addNode(node *yaml.Node, key string, value *yaml.Node) *yaml.Node {
+	keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}
+	node.Content = append(node.Content, keyNode, value)
+	return keyNode
+}
+
+// addScalar adds scalar value with given key and tag to mapping node
+func addScalar(node *yaml.Node, key, tag, value string) *yaml.Node {
+	return addNode(node, key, &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value})
+}
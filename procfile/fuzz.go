@@ -28,3 +28,29 @@ func FuzzV2(data []byte) int {
 
 	return 0
 }
+
+func FuzzCommand(data []byte) int {
+	tokens, err := Tokenize(string(data))
+
+	if err != nil {
+		return 0
+	}
+
+	parseCommand(string(data))
+
+	if len(tokens) != 0 {
+		return 1
+	}
+
+	return 0
+}
+
+func FuzzEnv(data []byte) int {
+	_, err := ParseEnv(data)
+
+	if err != nil {
+		return 0
+	}
+
+	return 1
+}
@@ -0,0 +1,228 @@
+package procfile
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                           Copyright (c) 2006-2024 FUNBOX                           //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/essentialkaos/ek/v13/errors"
+	"github.com/essentialkaos/go-simpleyaml/v2"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Default health check settings
+const (
+	DEFAULT_HEALTH_INTERVAL = 5  // Interval between checks in seconds
+	DEFAULT_HEALTH_TIMEOUT  = 3  // Timeout of every check in seconds
+	DEFAULT_HEALTH_RETRIES  = 10 // Max number of checks
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// HealthCheck contains info about service readiness check
+type HealthCheck struct {
+	Command  string // Command for checking service
+	TCP      string // Address (host:port or port) for TCP check
+	HTTP     string // URL for HTTP check
+	Interval int    // Interval between checks in seconds
+	Timeout  int    // Timeout of every check in seconds
+	Retries  int    // Max number of checks
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// v2HealthCheckProps contains known properties of health check
+var v2HealthCheckProps = []string{"command", "tcp", "http", "interval", "timeout", "retries"}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// HasHealthCheck returns true if service has readiness check
+func (s *Service) HasHealthCheck() bool {
+	return s.HealthCheck != nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// GetInterval returns interval between checks in seconds
+func (h *HealthCheck) GetInterval() int {
+	if h.Interval == 0 {
+		return DEFAULT_HEALTH_INTERVAL
+	}
+
+	return h.Interval
+}
+
+// GetTimeout returns timeout of every check in seconds
+func (h *HealthCheck) GetTimeout() int {
+	if h.Timeout == 0 {
+		return DEFAULT_HEALTH_TIMEOUT
+	}
+
+	return h.Timeout
+}
+
+// GetRetries returns max number of checks
+func (h *HealthCheck) GetRetries() int {
+	if h.Retries == 0 {
+		return DEFAULT_HEALTH_RETRIES
+	}
+
+	return h.Retries
+}
+
+// GetMaxDuration returns max duration of all checks in seconds
+func (h *HealthCheck) GetMaxDuration() int {
+	return h.GetRetries() * (h.GetInterval() + h.GetTimeout())
+}
+
+// GetTCPAddress returns host and port for TCP check (127.0.0.1 is used
+// if host is not set)
+func (h *HealthCheck) GetTCPAddress() (string, string) {
+	if !strings.Contains(h.TCP, ":") {
+		return "127.0.0.1", h.TCP
+	}
+
+	host, port, _ := net.SplitHostPort(h.TCP)
+
+	return host, port
+}
+
+// Validate validates health check properties
+func (h *HealthCheck) Validate() *errors.Bundle {
+	var errs errors.Bundle
+
+	var checks int
+
+	for _, value := range []string{h.Command, h.TCP, h.HTTP} {
+		if value != "" {
+			checks++
+		}
+	}
+
+	if checks != 1 {
+		errs.Add(&Error{
+			Rule:    RULE_INVALID_VALUE,
+			Path:    "healthcheck",
+			Message: "health check must contain only one of command, tcp or http",
+		})
+	}
+
+	if h.TCP != "" {
+		host, port := h.GetTCPAddress()
+		portNum, err := strconv.Atoi(port)
+
+		if host == "" || err != nil || portNum < 1 || portNum > MAX_PORT {
+			errs.Add(&Error{
+				Rule:    RULE_INVALID_VALUE,
+				Path:    "healthcheck.tcp",
+				Message: fmt.Sprintf("must contain port or host:port with port in range 1-%d", MAX_PORT),
+			})
+		}
+	}
+
+	if h.HTTP != "" && !strings.HasPrefix(h.HTTP, "http://") && !strings.HasPrefix(h.HTTP, "https://") {
+		errs.Add(&Error{Rule: RULE_INVALID_VALUE, Path: "healthcheck.http", Message: "must contain HTTP or HTTPS URL"})
+	}
+
+	if strings.ContainsAny(h.HTTP, " '\"") {
+		errs.Add(&Error{Rule: RULE_INVALID_VALUE, Path: "healthcheck.http", Message: "URL can't contain spaces and quotes"})
+	}
+
+	if h.Interval < 0 {
+		errs.Add(&Error{Rule: RULE_INVALID_VALUE, Path: "healthcheck.interval", Message: "must be greater or equal 0"})
+	}
+
+	if h.Timeout < 0 {
+		errs.Add(&Error{Rule: RULE_INVALID_VALUE, Path: "healthcheck.timeout", Message: "must be greater or equal 0"})
+	}
+
+	if h.Retries < 0 {
+		errs.Add(&Error{Rule: RULE_INVALID_VALUE, Path: "healthcheck.retries", Message: "must be greater or equal 0"})
+	}
+
+	return &errs
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// parseV2HealthCheck parse service health check
+func parseV2HealthCheck(yaml *simpleyaml.Yaml, prefix string) (*HealthCheck, error) {
+	var err error
+
+	prefix += "healthcheck"
+
+	if !yaml.IsMap() {
+		return nil, &Error{Rule: RULE_INVALID_TYPE, Path: prefix, Message: "expected map"}
+	}
+
+	check := &HealthCheck{}
+
+	if yaml.IsExist("command") {
+		check.Command = yamlGetSafe(yaml, "command")
+	}
+
+	if yaml.IsExist("tcp") {
+		check.TCP = yamlGetSafe(yaml, "tcp")
+	}
+
+	if yaml.IsExist("http") {
+		check.HTTP = yamlGetSafe(yaml, "http")
+	}
+
+	if yaml.IsExist("interval") {
+		check.Interval, err = yaml.Get("interval").Int()
+
+		if err != nil {
+			return nil, formatPropError(prefix+".interval", err)
+		}
+	}
+
+	if yaml.IsExist("timeout") {
+		check.Timeout, err = yaml.Get("timeout").Int()
+
+		if err != nil {
+			return nil, formatPropError(prefix+".timeout", err)
+		}
+	}
+
+	if yaml.IsExist("retries") {
+		check.Retries, err = yaml.Get("retries").Int()
+
+		if err != nil {
+			return nil, formatPropError(prefix+".retries", err)
+		}
+	}
+
+	return check, nil
+}
+
+// marshalHealthCheck encodes health check to mapping node
+func marshalHealthCheck(h *HealthCheck) *yaml.Node {
+	node := newMapNode()
+
+	addStringIfSet(node, "command", escapeVars(h.Command))
+	addStringIfSet(node, "tcp", escapeVars(h.TCP))
+	addStringIfSet(node, "http", escapeVars(h.HTTP))
+	addIntIfSet(node, "interval", h.Interval)
+	addIntIfSet(node, "timeout", h.Timeout)
+	addIntIfSet(node, "retries", h.Retries)
+
+	return node
+}
+
+// mapValues replaces all values which can contain variables using given function
+func (h *HealthCheck) mapValues(fn func(value string) string) {
+	h.Command = fn(h.Command)
+	h.TCP = fn(h.TCP)
+	h.HTTP = fn(h.HTTP)
+}
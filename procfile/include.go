@@ -0,0 +1,319 @@
+package procfile
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                           Copyright (c) 2006-2024 FUNBOX                           //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/essentialkaos/ek/v13/fsutil"
+	"github.com/essentialkaos/ek/v13/log"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// RULE_INVALID_INCLUDE is rule ID for errors in included files
+const RULE_INVALID_INCLUDE = "invalid-include"
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// v2IncludeProps contains names of properties with included files
+var v2IncludeProps = []string{"extends", "include"}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// includeLoader loads procfile with all included base files
+type includeLoader struct {
+	files map[*yaml.Node]string // Files of keys from included files
+	chain []string              // Chain of files which are loading now
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// readV2Procfile parse v2 procfile data and merges it with all included files
+func readV2Procfile(path string, data []byte, config *Config) (*Application, error) {
+	var doc yaml.Node
+
+	err := yaml.Unmarshal(data, &doc)
+
+	if err != nil || !hasIncludes(&doc) {
+		return parseV2Procfile(data, config)
+	}
+
+	loader := &includeLoader{files: make(map[*yaml.Node]string)}
+	root, err := loader.load(path, &doc)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return parseV2Node(data, root, loader.files, config)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// load merges document with all files included by it, values from document
+// override values from included files
+func (l *includeLoader) load(path string, doc *yaml.Node) (*yaml.Node, error) {
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, &Error{
+			Pos:     Position{File: l.fileName(path)},
+			Rule:    RULE_INVALID_INCLUDE,
+			Message: "Procfile must contain map with properties",
+		}
+	}
+
+	root := doc.Content[0]
+
+	expandAliases(root)
+
+	includes, err := l.getIncludes(path, root)
+
+	if err != nil {
+		return nil, err
+	}
+
+	l.chain = append(l.chain, path)
+
+	var result *yaml.Node
+
+	for _, include := range includes {
+		base, err := l.loadFile(include.path, include.key)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = mergeNodes(result, base)
+	}
+
+	l.chain = l.chain[:len(l.chain)-1]
+
+	return mergeNodes(result, withoutIncludes(root)), nil
+}
+
+// loadFile reads and loads included file
+func (l *includeLoader) loadFile(path string, key *yaml.Node) (*yaml.Node, error) {
+	if slices.ContainsFunc(l.chain, func(file string) bool { return isSameFile(file, path) }) {
+		return nil, l.newError(key, fmt.Sprintf(
+			"Include cycle detected (%s → %s)", strings.Join(l.chain, " → "), path,
+		))
+	}
+
+	log.Debug("Including file %s", path)
+
+	err := fsutil.ValidatePerms("FRS", path)
+
+	if err != nil {
+		return nil, l.newError(key, err.Error())
+	}
+
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, l.newError(key, err.Error())
+	}
+
+	var doc yaml.Node
+
+	err = yaml.Unmarshal(data, &doc)
+
+	if err != nil {
+		err = parseYAMLError(err)
+		e, ok := err.(*Error)
+
+		if ok {
+			e.Pos.File = path
+		}
+
+		return nil, err
+	}
+
+	markFile(&doc, path, l.files)
+
+	return l.load(path, &doc)
+}
+
+// getIncludes returns paths of files included by document
+func (l *includeLoader) getIncludes(path string, root *yaml.Node) ([]include, error) {
+	var result []include
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+
+		if !slices.Contains(v2IncludeProps, key.Value) {
+			continue
+		}
+
+		var items []*yaml.Node
+
+		switch value.Kind {
+		case yaml.ScalarNode:
+			items = []*yaml.Node{value}
+		case yaml.SequenceNode:
+			items = value.Content
+		}
+
+		if len(items) == 0 {
+			return nil, l.newError(key, "Value must be a path or list of paths")
+		}
+
+		for _, item := range items {
+			if item.Kind != yaml.ScalarNode || item.Value == "" {
+				return nil, l.newError(key, "Value must be a path or list of paths")
+			}
+
+			file := item.Value
+
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(filepath.Dir(path), file)
+			}
+
+			result = append(result, include{file, key})
+		}
+	}
+
+	return result, nil
+}
+
+// newError creates error for include property with given key
+func (l *includeLoader) newError(key *yaml.Node, message string) error {
+	return &Error{
+		Pos:     Position{File: l.files[key], Line: key.Line, Column: key.Column},
+		Rule:    RULE_INVALID_INCLUDE,
+		Path:    key.Value,
+		Message: message,
+	}
+}
+
+// fileName returns name of file for errors (empty for main procfile)
+func (l *includeLoader) fileName(path string) string {
+	if len(l.chain) == 0 {
+		return ""
+	}
+
+	return path
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// include contains info about included file
+type include struct {
+	path string
+	key  *yaml.Node
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// hasIncludes returns true if document includes other files
+func hasIncludes(doc *yaml.Node) bool {
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return false
+	}
+
+	root := doc.Content[0]
+
+	for i := 0; i < len(root.Content); i += 2 {
+		if slices.Contains(v2IncludeProps, root.Content[i].Value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// withoutIncludes returns copy of mapping node without include properties
+func withoutIncludes(node *yaml.Node) *yaml.Node {
+	return withoutKey(node, v2IncludeProps...)
+}
+
+// withoutKey returns copy of mapping node without properties with given keys
+func withoutKey(node *yaml.Node, keys ...string) *yaml.Node {
+	result := *node
+	result.Content = nil
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if !slices.Contains(keys, node.Content[i].Value) {
+			result.Content = append(result.Content, node.Content[i], node.Content[i+1])
+		}
+	}
+
+	return &result
+}
+
+// mergeNodes deeply merges mapping nodes, values from override node replace
+// values from base node
+func mergeNodes(base, override *yaml.Node) *yaml.Node {
+	if base == nil || base.Kind != yaml.MappingNode || override.Kind != yaml.MappingNode {
+		return override
+	}
+
+	result := *override
+	result.Content = slices.Clone(base.Content)
+
+	for i := 0; i+1 < len(override.Content); i += 2 {
+		key, value := override.Content[i], override.Content[i+1]
+		index := -1
+
+		for j := 0; j+1 < len(result.Content); j += 2 {
+			if result.Content[j].Value == key.Value {
+				index = j
+				break
+			}
+		}
+
+		if index == -1 {
+			result.Content = append(result.Content, key, value)
+			continue
+		}
+
+		result.Content[index] = key
+		result.Content[index+1] = mergeNodes(result.Content[index+1], value)
+	}
+
+	return &result
+}
+
+// expandAliases replaces all aliases with nodes they point to, so merged
+// document doesn't depend on anchors from replaced values
+func expandAliases(node *yaml.Node) {
+	node.Anchor = ""
+
+	for i, child := range node.Content {
+		if child.Kind == yaml.AliasNode && child.Alias != nil {
+			node.Content[i] = child.Alias
+		}
+
+		expandAliases(node.Content[i])
+	}
+}
+
+// isSameFile returns true if both paths point to the same file
+func isSameFile(path1, path2 string) bool {
+	abs1, _ := filepath.Abs(path1)
+	abs2, _ := filepath.Abs(path2)
+
+	return abs1 == abs2
+}
+
+// markFile saves file of all keys in document
+func markFile(node *yaml.Node, file string, files map[*yaml.Node]string) {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i < len(node.Content); i += 2 {
+			files[node.Content[i]] = file
+		}
+	}
+
+	for _, child := range node.Content {
+		markFile(child, file, files)
+	}
+}
@@ -0,0 +1,284 @@
+package procfile
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                           Copyright (c) 2006-2024 FUNBOX                           //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Lint rules IDs
+const (
+	LINT_NO_KILL_TIMEOUT       = "no-kill-timeout"
+	LINT_LOW_NOFILE            = "low-nofile"
+	LINT_MEMORY_HIGH_ABOVE_MAX = "memory-high-above-max"
+	LINT_COUNT_ABOVE_CPUS      = "count-above-cpus"
+	LINT_KILL_MODE_NONE        = "kill-mode-none"
+	LINT_UPSTART_RESOURCES     = "upstart-resources"
+)
+
+// Lint warnings severities
+const (
+	SEVERITY_ERROR   = "error"
+	SEVERITY_WARNING = "warning"
+	SEVERITY_OFF     = "off"
+)
+
+// MIN_NOFILE is minimal recommended descriptors limit
+const MIN_NOFILE = 1024
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// LintRules contains IDs of all lint rules
+var LintRules = []string{
+	LINT_NO_KILL_TIMEOUT,
+	LINT_LOW_NOFILE,
+	LINT_MEMORY_HIGH_ABOVE_MAX,
+	LINT_COUNT_ABOVE_CPUS,
+	LINT_KILL_MODE_NONE,
+	LINT_UPSTART_RESOURCES,
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// LintConfig contains lint configuration
+type LintConfig struct {
+	Format   string            // Format of generated configs (upstart/systemd)
+	Severity map[string]string // Custom severities of rules (error/warning/off)
+}
+
+// Warning is procfile lint warning
+type Warning struct {
+	Pos      Position // Position of property in procfile
+	Severity string   // Severity (error/warning)
+	Rule     string   // Rule ID
+	Service  string   // Service name
+	Path     string   // Property path (i.e. commands.web.limits.nofile)
+	Message  string   // Warning message
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Error returns warning text in compiler-like format
+func (w *Warning) Error() string {
+	return formatProblem(w.Pos, w.Path, w.Message)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Lint checks all services in application for risky configuration
+func (a *Application) Lint(config *LintConfig) []*Warning {
+	if config == nil {
+		config = &LintConfig{}
+	}
+
+	var result []*Warning
+
+	for _, service := range a.Services {
+		for _, w := range service.lint(config) {
+			w.Severity = config.severity(w.Rule)
+
+			if w.Severity == SEVERITY_OFF {
+				continue
+			}
+
+			w.Service = service.Name
+			w.Path, w.Pos = a.source.locate(service.Name, w.Path)
+
+			if a.source.isIgnored(w.Rule, service.Name, w.Pos) {
+				continue
+			}
+
+			result = append(result, w)
+		}
+	}
+
+	return result
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// lint checks service options for risky configuration
+func (s *Service) lint(config *LintConfig) []*Warning {
+	var result []*Warning
+
+	so := s.Options
+
+	if so == nil {
+		return nil
+	}
+
+	if !so.IsRespawnEnabled && !s.isKillTimeoutSet() {
+		result = append(result, &Warning{
+			Rule:    LINT_NO_KILL_TIMEOUT,
+			Path:    "respawn",
+			Message: "respawn is disabled and kill_timeout is not set, service can hang on stop",
+		})
+	}
+
+	if so.LimitFile > 0 && so.LimitFile < MIN_NOFILE {
+		result = append(result, &Warning{
+			Rule:    LINT_LOW_NOFILE,
+			Path:    "limits.nofile",
+			Message: fmt.Sprintf("descriptors limit %d is less than %d", so.LimitFile, MIN_NOFILE),
+		})
+	}
+
+	if so.KillMode == "none" {
+		result = append(result, &Warning{
+			Rule:    LINT_KILL_MODE_NONE,
+			Path:    "kill_mode",
+			Message: "processes will not be killed on stop and can outlive service",
+		})
+	}
+
+	if so.Resources == nil {
+		return result
+	}
+
+	if config.Format == "upstart" {
+		result = append(result, &Warning{
+			Rule:    LINT_UPSTART_RESOURCES,
+			Path:    "resources",
+			Message: "resources limits are not supported by upstart and will be ignored",
+		})
+	}
+
+	memHigh, memMax := parseMemorySize(so.Resources.MemoryHigh), parseMemorySize(so.Resources.MemoryMax)
+
+	if memHigh > 0 && memMax > 0 && memHigh > memMax {
+		result = append(result, &Warning{
+			Rule:    LINT_MEMORY_HIGH_ABOVE_MAX,
+			Path:    "resources.memory_high",
+			Message: fmt.Sprintf("memory_high (%s) is greater than memory_max (%s)", so.Resources.MemoryHigh, so.Resources.MemoryMax),
+		})
+	}
+
+	cpus := countAffinityCPUs(so.Resources.CPUAffinity)
+
+	if cpus > 0 && so.Count > cpus {
+		result = append(result, &Warning{
+			Rule:    LINT_COUNT_ABOVE_CPUS,
+			Path:    "count",
+			Message: fmt.Sprintf("count (%d) is greater than number of CPUs in cpu_affinity (%d)", so.Count, cpus),
+		})
+	}
+
+	return result
+}
+
+// isKillTimeoutSet returns true if kill timeout is set in procfile for service
+// or whole application (default timeout from configuration is not counted)
+func (s *Service) isKillTimeoutSet() bool {
+	if s.Application == nil || s.Application.source == nil {
+		return s.Options.KillTimeout != 0
+	}
+
+	_, isServiceSet := s.Application.source.find("commands." + s.Name + ".kill_timeout")
+	_, isAppSet := s.Application.source.find("kill_timeout")
+
+	return isServiceSet || isAppSet
+}
+
+// severity returns severity of rule
+func (c *LintConfig) severity(rule string) string {
+	switch c.Severity[rule] {
+	case SEVERITY_ERROR, SEVERITY_OFF:
+		return c.Severity[rule]
+	}
+
+	return SEVERITY_WARNING
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// parseMemorySize parses memory size in systemd format (i.e. 512M) and
+// returns size in bytes or 0 if size is not set or relative
+func parseMemorySize(size string) uint64 {
+	size = strings.TrimSpace(size)
+
+	if size == "" {
+		return 0
+	}
+
+	var mod uint64 = 1
+
+	switch size[len(size)-1] {
+	case 'K', 'k':
+		mod = 1 << 10
+	case 'M', 'm':
+		mod = 1 << 20
+	case 'G', 'g':
+		mod = 1 << 30
+	case 'T', 't':
+		mod = 1 << 40
+	}
+
+	if mod != 1 {
+		size = size[:len(size)-1]
+	}
+
+	value, err := strconv.ParseUint(size, 10, 64)
+
+	if err != nil {
+		return 0
+	}
+
+	return value * mod
+}
+
+// countAffinityCPUs returns number of CPUs in CPU affinity list (0 if list is
+// misformatted or contains CPU numbers greater than MAX_CPU)
+func countAffinityCPUs(affinity string) int {
+	var ranges [][2]int
+
+	for _, item := range strings.FieldsFunc(affinity, func(r rune) bool { return r == ',' || r == ' ' }) {
+		from, to, isRange := strings.Cut(item, "-")
+
+		start, err := strconv.Atoi(from)
+
+		if err != nil {
+			return 0
+		}
+
+		end := start
+
+		if isRange {
+			end, err = strconv.Atoi(to)
+
+			if err != nil {
+				return 0
+			}
+		}
+
+		if start < 0 || end < start || end > MAX_CPU {
+			return 0
+		}
+
+		ranges = append(ranges, [2]int{start, end})
+	}
+
+	slices.SortFunc(ranges, func(a, b [2]int) int { return a[0] - b[0] })
+
+	count, last := 0, -1
+
+	// Overlapping ranges are counted once
+	for _, r := range ranges {
+		if r[1] <= last {
+			continue
+		}
+
+		count += r[1] - max(r[0], last+1) + 1
+		last = r[1]
+	}
+
+	return count
+}
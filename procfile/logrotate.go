@@ -0,0 +1,169 @@
+package procfile
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                           Copyright (c) 2006-2024 FUNBOX                           //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+
+	"github.com/essentialkaos/ek/v13/errors"
+	"github.com/essentialkaos/go-simpleyaml/v2"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// REGEXP_LOG_SIZE_CHECK contains regexp for checking max size of log file
+const REGEXP_LOG_SIZE_CHECK = `\A[1-9][0-9]*[kMG]?\z`
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Logrotate contains rotation policy for logs of application
+type Logrotate struct {
+	Period     string // Rotation period (daily, weekly, monthly or yearly)
+	Rotate     int    // Number of rotated files to keep
+	MaxSize    string // Rotate file before period ends if it grows bigger (i.e. 100M)
+	Compress   bool   // Compress rotated files
+	IsDisabled bool   // Don't generate logrotate configuration
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// v2LogrotateProps contains known properties of logrotate
+var v2LogrotateProps = []string{"period", "rotate", "max_size", "compress"}
+
+// logrotatePeriods contains supported rotation periods
+var logrotatePeriods = []string{"daily", "weekly", "monthly", "yearly"}
+
+// defaultLogrotate contains rotation policy used if policy is not set in
+// configuration
+var defaultLogrotate = Logrotate{Period: "weekly", Rotate: 4, Compress: true}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// IsLogrotateEnabled returns true if logrotate configuration must be generated
+// for application
+func (a *Application) IsLogrotateEnabled() bool {
+	return a.Logrotate != nil && !a.Logrotate.IsDisabled
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Validate validates rotation policy
+func (l *Logrotate) Validate() *errors.Bundle {
+	var errs errors.Bundle
+
+	if l.IsDisabled {
+		return &errs
+	}
+
+	if !slices.Contains(logrotatePeriods, l.Period) {
+		errs.Add(&Error{Rule: RULE_INVALID_VALUE, Path: "logrotate.period", Message: "must contain 'daily', 'weekly', 'monthly' or 'yearly'"})
+	}
+
+	if l.Rotate < 0 {
+		errs.Add(&Error{Rule: RULE_INVALID_VALUE, Path: "logrotate.rotate", Message: "must be greater or equal 0"})
+	}
+
+	if l.MaxSize != "" && !regexp.MustCompile(REGEXP_LOG_SIZE_CHECK).MatchString(l.MaxSize) {
+		errs.Add(&Error{Rule: RULE_INVALID_VALUE, Path: "logrotate.max_size", Message: "must contain number with optional unit (k, M or G)"})
+	}
+
+	return &errs
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// newLogrotate returns copy of rotation policy from configuration
+func newLogrotate(config *Config) *Logrotate {
+	if config.Logrotate == nil {
+		return nil
+	}
+
+	logrotate := *config.Logrotate
+
+	return &logrotate
+}
+
+// parseV2Logrotate parse rotation policy defined as boolean or map with
+// properties, properties which are not set are taken from configuration
+func parseV2Logrotate(yaml *simpleyaml.Yaml, config *Config) (*Logrotate, error) {
+	var err error
+
+	if !yaml.IsExist("logrotate") {
+		return newLogrotate(config), nil
+	}
+
+	logrotate := newLogrotate(config)
+
+	if logrotate == nil {
+		defaults := defaultLogrotate
+		logrotate = &defaults
+	}
+
+	logrotateYaml := yaml.Get("logrotate")
+
+	if !logrotateYaml.IsMap() {
+		var isEnabled bool
+
+		isEnabled, err = logrotateYaml.Bool()
+
+		if err != nil {
+			return nil, formatPropError("logrotate", err)
+		}
+
+		logrotate.IsDisabled = !isEnabled
+
+		return logrotate, nil
+	}
+
+	logrotate.IsDisabled = false
+
+	if logrotateYaml.IsExist("period") {
+		logrotate.Period = yamlGetSafe(logrotateYaml, "period")
+	}
+
+	if logrotateYaml.IsExist("rotate") {
+		logrotate.Rotate, err = logrotateYaml.Get("rotate").Int()
+
+		if err != nil {
+			return nil, formatPropError("logrotate.rotate", err)
+		}
+	}
+
+	if logrotateYaml.IsExist("max_size") {
+		logrotate.MaxSize = yamlGetSafe(logrotateYaml, "max_size")
+	}
+
+	if logrotateYaml.IsExist("compress") {
+		logrotate.Compress, err = logrotateYaml.Get("compress").Bool()
+
+		if err != nil {
+			return nil, formatPropError("logrotate.compress", err)
+		}
+	}
+
+	return logrotate, nil
+}
+
+// marshalLogrotate encodes rotation policy to node
+func marshalLogrotate(l *Logrotate) *yaml.Node {
+	if l.IsDisabled {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "false"}
+	}
+
+	node := newMapNode()
+
+	addStringIfSet(node, "period", l.Period)
+	addInt(node, "rotate", l.Rotate)
+	addStringIfSet(node, "max_size", l.MaxSize)
+	addScalar(node, "compress", "!!bool", strconv.FormatBool(l.Compress))
+
+	return node
+}
@@ -0,0 +1,610 @@
+package procfile
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                           Copyright (c) 2006-2024 FUNBOX                           //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Marshal encodes application to procfile with given version
+func Marshal(app *Application, version int) ([]byte, error) {
+	if app == nil {
+		return nil, fmt.Errorf("Application is nil")
+	}
+
+	if version != 2 {
+		return nil, fmt.Errorf("Procfile version %d is not supported for encoding", version)
+	}
+
+	root, commands := newMapNode(), newMapNode()
+
+	addScalar(root, "version", "!!int", "2")
+
+	// Working directory is set on application level only if it doesn't change
+	// working directory of any command after merging
+	isAppDirSet := app.WorkingDir != "" && len(app.Services) != 0
+
+	for _, service := range app.Services {
+		if service.Options == nil || service.Options.WorkingDir == "" {
+			isAppDirSet = false
+		}
+	}
+
+	// User and group are set on application level if any command uses them
+	// after merging
+	isAppUserSet := app.User != "" && hasServiceWithOption(app, func(so *ServiceOptions) bool {
+		return so.User == app.User
+	})
+
+	isAppGroupSet := app.Group != "" && hasServiceWithOption(app, func(so *ServiceOptions) bool {
+		return so.Group == app.Group
+	})
+
+	if app.StartLevel != 3 {
+		addInt(root, "start_on_runlevel", app.StartLevel)
+	}
+
+	if app.StopLevel != 3 {
+		addInt(root, "stop_on_runlevel", app.StopLevel)
+	}
+
+	if app.StartDevice != "" {
+		addScalar(root, "start_on_device", "!!str", app.StartDevice)
+	}
+
+	if app.StrongDependencies {
+		addScalar(root, "strong_dependencies", "!!bool", "true")
+	}
+
+	if len(app.Depends) != 0 {
+		addScalar(root, "depends", "!!str", strings.Join(app.Depends, " "))
+	}
+
+	if isAppDirSet {
+		addScalar(root, "working_directory", "!!str", escapeVars(app.WorkingDir))
+	}
+
+	if isAppUserSet {
+		addScalar(root, "user", "!!str", app.User)
+	}
+
+	if isAppGroupSet {
+		addScalar(root, "group", "!!str", app.Group)
+	}
+
+	respawn, err := getCommonRespawn(app)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if respawn != nil {
+		addNode(root, "respawn", respawn)
+	}
+
+	if app.Logrotate != nil {
+		addNode(root, "logrotate", marshalLogrotate(app.Logrotate))
+	}
+
+	addNode(root, "commands", commands)
+
+	for _, service := range app.Services {
+		node := newMapNode()
+
+		addCommand(node, "command", service.Cmd, service.CmdArgs)
+
+		if service.HasPreCmd() {
+			addCommand(node, "pre", service.PreCmd, service.PreCmdArgs)
+		}
+
+		if service.HasPostCmd() {
+			addCommand(node, "post", service.PostCmd, service.PostCmdArgs)
+		}
+
+		addIntIfSet(node, "port", service.Port)
+		addIntIfSet(node, "priority", service.Priority)
+
+		if len(service.DependsOn) != 0 {
+			addList(node, "depends_on", service.DependsOn)
+		}
+
+		if service.HasHealthCheck() {
+			addNode(node, "healthcheck", marshalHealthCheck(service.HealthCheck))
+		}
+
+		if service.HasSockets() {
+			addNode(node, "sockets", marshalSockets(service.Sockets))
+		}
+
+		if service.Options != nil {
+			options := *service.Options
+
+			if isAppUserSet && options.User == app.User {
+				options.User = ""
+			}
+
+			if isAppGroupSet && options.Group == app.Group {
+				options.Group = ""
+			}
+
+			marshalOptions(node, &options, isAppDirSet && options.WorkingDir == app.WorkingDir)
+		}
+
+		addNode(commands, service.Name, node)
+	}
+
+	if len(app.Schedules) != 0 {
+		schedules := newMapNode()
+
+		for _, schedule := range app.Schedules {
+			options := *schedule.Options
+
+			if isAppUserSet && options.User == app.User {
+				options.User = ""
+			}
+
+			if isAppGroupSet && options.Group == app.Group {
+				options.Group = ""
+			}
+
+			addNode(schedules, schedule.Name, marshalSchedule(schedule, &options, isAppDirSet && options.WorkingDir == app.WorkingDir))
+		}
+
+		addNode(root, "schedules", schedules)
+	}
+
+	if len(app.Tasks) != 0 {
+		tasks := newMapNode()
+
+		for _, task := range app.Tasks {
+			options := *task.Options
+
+			if isAppUserSet && options.User == app.User {
+				options.User = ""
+			}
+
+			if isAppGroupSet && options.Group == app.Group {
+				options.Group = ""
+			}
+
+			addNode(tasks, task.Name, marshalTask(task, &options, isAppDirSet && options.WorkingDir == app.WorkingDir))
+		}
+
+		addNode(root, "tasks", tasks)
+	}
+
+	return encodeDocument(root)
+}
+
+// Format formats procfile v2 data with canonical order of properties
+// and indentation
+func Format(data []byte) ([]byte, error) {
+	var doc yaml.Node
+
+	if determineProcVersion(data) != 2 {
+		return nil, fmt.Errorf("Only procfile v2 can be formatted")
+	}
+
+	err := yaml.Unmarshal(data, &doc)
+
+	if err != nil {
+		return nil, parseYAMLError(err)
+	}
+
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("Procfile must contain map with properties")
+	}
+
+	root := doc.Content[0]
+
+	sortNodeKeys(root, v2AppProps, v2OptionsProps, v2SectionProps)
+	sortNodeKeys(getNodeValue(root, "logrotate"), v2LogrotateProps)
+	formatV2Options(root)
+
+	profiles := getNodeValue(root, "profiles")
+
+	if profiles != nil && profiles.Kind == yaml.MappingNode {
+		for i := 1; i < len(profiles.Content); i += 2 {
+			formatV2Profile(profiles.Content[i])
+		}
+	}
+
+	formatV2Commands(getNodeValue(root, "commands"))
+	formatV2Schedules(getNodeValue(root, "schedules"))
+	formatV2Tasks(getNodeValue(root, "tasks"))
+
+	return encodeDocument(&doc)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// hasServiceWithOption returns true if options of any service match given
+// condition
+func hasServiceWithOption(app *Application, cond func(so *ServiceOptions) bool) bool {
+	for _, service := range app.Services {
+		if service.Options != nil && cond(service.Options) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getCommonRespawn returns application level respawn limits which are required
+// for commands with disabled respawn (these limits can't be defined on
+// command level)
+func getCommonRespawn(app *Application) (*yaml.Node, error) {
+	var count, interval int
+
+	for _, service := range app.Services {
+		if service.Options == nil || service.Options.IsRespawnEnabled || !service.Options.IsRespawnLimitSet() {
+			continue
+		}
+
+		if service.Options.RespawnDelay != 0 ||
+			(count != 0 && count != service.Options.RespawnCount) ||
+			(interval != 0 && interval != service.Options.RespawnInterval) {
+			return nil, fmt.Errorf("Respawn limits of command %s with disabled respawn can't be encoded", service.Name)
+		}
+
+		count, interval = service.Options.RespawnCount, service.Options.RespawnInterval
+	}
+
+	if count == 0 && interval == 0 {
+		return nil, nil
+	}
+
+	for _, service := range app.Services {
+		if service.Options == nil {
+			continue
+		}
+
+		if (count != 0 && service.Options.RespawnCount == 0) || (interval != 0 && service.Options.RespawnInterval == 0) {
+			return nil, fmt.Errorf("Respawn limits of command %s can't be encoded", service.Name)
+		}
+	}
+
+	node := newMapNode()
+
+	addIntIfSet(node, "count", count)
+	addIntIfSet(node, "interval", interval)
+
+	return node, nil
+}
+
+// marshalOptions adds service options to command node
+func marshalOptions(node *yaml.Node, options *ServiceOptions, skipWorkingDir bool) {
+	if options.WorkingDir != "" && !skipWorkingDir {
+		addScalar(node, "working_directory", "!!str", escapeVars(options.WorkingDir))
+	}
+
+	addStringIfSet(node, "user", options.User)
+	addStringIfSet(node, "group", options.Group)
+
+	if options.LogFile != "" {
+		addScalar(node, "log", "!!str", escapeVars(options.LogFile))
+	}
+
+	if options.KillTimeout != 0 {
+		addInt(node, "kill_timeout", options.KillTimeout)
+	}
+
+	if options.KillSignal != "" {
+		addScalar(node, "kill_signal", "!!str", options.KillSignal)
+	}
+
+	if options.KillMode != "" {
+		addScalar(node, "kill_mode", "!!str", options.KillMode)
+	}
+
+	if options.ReloadSignal != "" {
+		addScalar(node, "reload_signal", "!!str", options.ReloadSignal)
+	}
+
+	addStringIfSet(node, "type", options.Type)
+	addStringIfSet(node, "notify_access", options.NotifyAccess)
+
+	if options.PIDFile != "" {
+		addScalar(node, "pid_file", "!!str", escapeVars(options.PIDFile))
+	}
+
+	addIntIfSet(node, "watchdog_sec", options.WatchdogSec)
+
+	if options.Count != 0 {
+		addInt(node, "count", options.Count)
+	}
+
+	if options.IsEnvSet() {
+		env := newMapNode()
+
+		for _, name := range slices.Sorted(maps.Keys(options.Env)) {
+			addScalar(env, name, "!!str", escapeVars(options.Env[name]))
+		}
+
+		addNode(node, "env", env)
+	}
+
+	if options.IsEnvFileSet() {
+		addEnvFiles(node, options.EnvFiles)
+	}
+
+	switch {
+	case !options.IsRespawnEnabled:
+		addScalar(node, "respawn", "!!bool", "false")
+	case options.IsRespawnLimitSet():
+		respawn := newMapNode()
+
+		addIntIfSet(respawn, "count", options.RespawnCount)
+		addIntIfSet(respawn, "interval", options.RespawnInterval)
+		addIntIfSet(respawn, "delay", options.RespawnDelay)
+
+		addNode(node, "respawn", respawn)
+	}
+
+	if options.IsFileLimitSet() || options.IsProcLimitSet() || options.IsMemlockLimitSet() {
+		limits := newMapNode()
+
+		addIntIfSet(limits, "nofile", options.LimitFile)
+		addIntIfSet(limits, "nproc", options.LimitProc)
+		addIntIfSet(limits, "memlock", options.LimitMemlock)
+
+		addNode(node, "limits", limits)
+	}
+
+	if options.IsResourcesSet() {
+		addNode(node, "resources", marshalResources(options.Resources))
+	}
+
+	if options.Security != nil {
+		addNode(node, "security", marshalSecurity(options.Security))
+	}
+
+	if options.IsSecretsSet() {
+		addNode(node, "secrets", marshalSecrets(options.Secrets))
+	}
+}
+
+// escapeVars escapes variables references in value, so they are not resolved
+// again while parsing (references to INSTANCE and PORT are resolved on export)
+func escapeVars(value string) string {
+	value = strings.ReplaceAll(value, "${", "$${")
+	value = strings.ReplaceAll(value, "$${"+VAR_INSTANCE+"}", "${"+VAR_INSTANCE+"}")
+	return strings.ReplaceAll(value, "$${"+VAR_PORT+"}", "${"+VAR_PORT+"}")
+}
+
+// marshalResources creates node with resources limits
+func marshalResources(r *Resources) *yaml.Node {
+	node := newMapNode()
+
+	addIntIfSet(node, "cpu_weight", r.CPUWeight)
+	addIntIfSet(node, "startup_cpu_weight", r.StartupCPUWeight)
+	addIntIfSet(node, "cpu_quota", r.CPUQuota)
+	addStringIfSet(node, "cpu_affinity", r.CPUAffinity)
+	addStringIfSet(node, "memory_low", r.MemoryLow)
+	addStringIfSet(node, "memory_high", r.MemoryHigh)
+	addStringIfSet(node, "memory_max", r.MemoryMax)
+	addStringIfSet(node, "memory_swap_max", r.MemorySwapMax)
+	addIntIfSet(node, "task_max", r.TasksMax)
+	addIntIfSet(node, "io_weight", r.IOWeight)
+	addIntIfSet(node, "startup_io_weight", r.StartupIOWeight)
+	addStringIfSet(node, "io_device_weight", r.IODeviceWeight)
+	addStringIfSet(node, "io_read_bandwidth_max", r.IOReadBandwidthMax)
+	addStringIfSet(node, "io_write_bandwidth_max", r.IOWriteBandwidthMax)
+	addStringIfSet(node, "io_read_iops_max", r.IOReadIOPSMax)
+	addStringIfSet(node, "io_write_iops_max", r.IOWriteIOPSMax)
+	addStringIfSet(node, "ip_address_allow", r.IPAddressAllow)
+	addStringIfSet(node, "ip_address_deny", r.IPAddressDeny)
+
+	return node
+}
+
+// formatV2Profile sorts properties of profile
+func formatV2Profile(profile *yaml.Node) {
+	sortNodeKeys(profile, v2AppProps, v2OptionsProps, v2SectionProps)
+	sortNodeKeys(getNodeValue(profile, "logrotate"), v2LogrotateProps)
+	formatV2Options(profile)
+	formatV2Commands(getNodeValue(profile, "commands"))
+	formatV2Schedules(getNodeValue(profile, "schedules"))
+	formatV2Tasks(getNodeValue(profile, "tasks"))
+}
+
+// formatV2Commands sorts properties of all commands
+func formatV2Commands(commands *yaml.Node) {
+	if commands == nil || commands.Kind != yaml.MappingNode {
+		return
+	}
+
+	for i := 1; i < len(commands.Content); i += 2 {
+		command := commands.Content[i]
+
+		if command.Kind != yaml.MappingNode {
+			continue
+		}
+
+		sortNodeKeys(command, v2ServiceProps, v2OptionsProps)
+		sortNodeKeys(getNodeValue(command, "healthcheck"), v2HealthCheckProps)
+		sortNodeKeys(getNodeValue(command, "sockets"), v2SocketsProps)
+		formatV2Options(command)
+	}
+}
+
+// formatV2Schedules sorts properties of all scheduled jobs
+func formatV2Schedules(schedules *yaml.Node) {
+	if schedules == nil || schedules.Kind != yaml.MappingNode {
+		return
+	}
+
+	for i := 1; i < len(schedules.Content); i += 2 {
+		sortNodeKeys(schedules.Content[i], v2ScheduleProps, v2JobOptionsProps)
+	}
+}
+
+// formatV2Tasks sorts properties of all tasks
+func formatV2Tasks(tasks *yaml.Node) {
+	if tasks == nil || tasks.Kind != yaml.MappingNode {
+		return
+	}
+
+	for i := 1; i < len(tasks.Content); i += 2 {
+		sortNodeKeys(tasks.Content[i], v2TaskProps, v2JobOptionsProps)
+	}
+}
+
+// formatV2Options sorts properties in nested options sections
+func formatV2Options(node *yaml.Node) {
+	sortNodeKeys(getNodeValue(node, "respawn"), v2RespawnProps)
+	sortNodeKeys(getNodeValue(node, "limits"), v2LimitsProps)
+	sortNodeKeys(getNodeValue(node, "resources"), v2ResourcesProps)
+	sortNodeKeys(getNodeValue(node, "security"), v2SecurityProps)
+
+	if secrets := getNodeValue(node, "secrets"); secrets != nil && secrets.Kind == yaml.MappingNode {
+		for i := 1; i < len(secrets.Content); i += 2 {
+			sortNodeKeys(secrets.Content[i], v2SecretProps)
+		}
+	}
+}
+
+// sortNodeKeys sorts keys of mapping node in order of given lists, unknown keys
+// are placed to the end in original order
+func sortNodeKeys(node *yaml.Node, order ...[]string) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return
+	}
+
+	var keys []string
+
+	for _, list := range order {
+		keys = append(keys, list...)
+	}
+
+	type pair struct{ key, value *yaml.Node }
+
+	var pairs []pair
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		pairs = append(pairs, pair{node.Content[i], node.Content[i+1]})
+	}
+
+	slices.SortStableFunc(pairs, func(a, b pair) int {
+		return keyIndex(keys, a.key.Value) - keyIndex(keys, b.key.Value)
+	})
+
+	node.Content = node.Content[:0]
+
+	for _, p := range pairs {
+		node.Content = append(node.Content, p.key, p.value)
+	}
+}
+
+// keyIndex returns index of key in canonical order
+func keyIndex(keys []string, key string) int {
+	index := slices.Index(keys, key)
+
+	if index == -1 {
+		return len(keys)
+	}
+
+	return index
+}
+
+// getNodeValue returns value of mapping node with given key
+func getNodeValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+// addCommand adds command as string or as list of arguments to mapping node
+func addCommand(node *yaml.Node, key, cmd string, args []string) {
+	if args == nil {
+		addScalar(node, key, "!!str", escapeVars(cmd))
+		return
+	}
+
+	list := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+
+	for _, arg := range args {
+		list.Content = append(list.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: escapeVars(arg)})
+	}
+
+	addNode(node, key, list)
+}
+
+// addList adds list of strings with given key to mapping node
+func addList(node *yaml.Node, key string, values []string) {
+	list := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Style: yaml.FlowStyle}
+
+	for _, value := range values {
+		list.Content = append(list.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value})
+	}
+
+	addNode(node, key, list)
+}
+
+// addEnvFiles adds env files as path or as list of paths and maps with
+// required flag to mapping node
+func addEnvFiles(node *yaml.Node, files []EnvFile) {
+	if len(files) == 1 && files[0].Required {
+		addScalar(node, "env_file", "!!str", escapeVars(files[0].Path))
+		return
+	}
+
+	list := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+
+	for _, file := range files {
+		path := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: escapeVars(file.Path)}
+
+		if file.Required {
+			list.Content = append(list.Content, path)
+			continue
+		}
+
+		item := newMapNode()
+		addNode(item, "path", path)
+		addScalar(item, "required", "!!bool", "false")
+		list.Content = append(list.Content, item)
+	}
+
+	addNode(node, "env_file", list)
+}
+
+// addInt adds integer value with given key to mapping node
+func addInt(node *yaml.Node, key string, value int) {
+	addScalar(node, key, "!!int", strconv.Itoa(value))
+}
+
+// addIntIfSet adds integer value with given key to mapping node if value
+// is not zero
+func addIntIfSet(node *yaml.Node, key string, value int) {
+	if value != 0 {
+		addInt(node, key, value)
+	}
+}
+
+// addStringIfSet adds string value with given key to mapping node if value
+// is not empty
+func addStringIfSet(node *yaml.Node, key, value string) {
+	if value != "" {
+		addScalar(node, key, "!!str", value)
+	}
+}
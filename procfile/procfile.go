@@ -8,17 +8,19 @@ package procfile
 
 import (
 	"fmt"
+	"maps"
 	"os"
 	"regexp"
 	"slices"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/essentialkaos/ek/v13/errors"
 	"github.com/essentialkaos/ek/v13/fsutil"
 	"github.com/essentialkaos/ek/v13/log"
 	"github.com/essentialkaos/ek/v13/path"
-	"github.com/essentialkaos/ek/v13/strutil"
+	"github.com/essentialkaos/ek/v13/system"
 )
 
 // ////////////////////////////////////////////////////////////////////////////////// //
@@ -28,24 +30,36 @@ const (
 	REGEXP_V2_VERSION         = `(?m)^\s*version:\s*2\s*$`
 	REGEXP_PATH_CHECK         = `\A[A-Za-z0-9_\-./]+\z`
 	REGEXP_NAME_CHECK         = `\A[A-Za-z0-9_\-]+\z`
+	REGEXP_USER_CHECK         = `\A[A-Za-z0-9_][A-Za-z0-9_.\-]*\$?\z`
 	REGEXP_NET_DEVICE_CHECK   = `eth[0-9]|e[nm][0-9]|p[0-9][ps][0-9]|wlan|wl[0-9]|wlp[0-9]|bond[0-9]`
 	REGEXP_CPU_AFFINITY_CHECK = `^[\d\-, ]+$`
 )
 
+// MAX_PORT is maximum port number
+const MAX_PORT = 65535
+
+// MAX_CPU is maximum CPU number in CPU affinity list
+const MAX_CPU = 8191
+
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 type Config struct {
-	Name             string // Application name
-	User             string // Working user
-	Group            string // Working group
-	WorkingDir       string // Working directory
-	RespawnInterval  int    // Global respawn interval in seconds
-	RespawnCount     int    // Global respawn count
-	KillTimeout      int    // Global kill timeout in seconds
-	LimitProc        int    // Global processes limit
-	LimitFile        int    // Global descriptors limit
-	LimitMemlock     int    // Global max locked memory limit
-	IsRespawnEnabled bool   // Global respawn enabled flag
+	Name             string     // Application name
+	User             string     // Working user
+	Group            string     // Working group
+	WorkingDir       string     // Working directory
+	RespawnInterval  int        // Global respawn interval in seconds
+	RespawnCount     int        // Global respawn count
+	KillTimeout      int        // Global kill timeout in seconds
+	LimitProc        int        // Global processes limit
+	LimitFile        int        // Global descriptors limit
+	LimitMemlock     int        // Global max locked memory limit
+	IsRespawnEnabled bool       // Global respawn enabled flag
+	IsStrict         bool       // Report unknown properties as errors
+	UseEnvVars       bool       // Resolve variables in procfile from environment
+	Profile          string     // Name of profile applied to procfile
+	SecurityPreset   string     // Global security preset
+	Logrotate        *Logrotate // Global rotation policy for logs (nil if disabled)
 }
 
 type Service struct {
@@ -53,19 +67,34 @@ type Service struct {
 	Cmd         string          // Command
 	PreCmd      string          // Pre command
 	PostCmd     string          // Post command
+	CmdArgs     []string        // Command arguments (if command defined as list)
+	PreCmdArgs  []string        // Pre command arguments (if command defined as list)
+	PostCmdArgs []string        // Post command arguments (if command defined as list)
+	Port        int             // Base port (first instance port)
+	Priority    int             // Start priority (services with lower priority start first)
+	DependsOn   []string        // Services which must be started before service
+	HealthCheck *HealthCheck    // Readiness check
+	Sockets     *Sockets        // Sockets for socket activation (systemd only)
 	Options     *ServiceOptions // Service options
 	Application *Application    // Pointer to parent application
 	HelperPath  string          // Path to helper (will be set by exporter)
+	CheckerPath string          // Path to health checker (will be set by exporter)
 }
 
 type ServiceOptions struct {
 	Env              map[string]string // Environment variables
-	EnvFile          string            // Path to file with environment variables
+	User             string            // Working user
+	Group            string            // Working group
+	EnvFiles         []EnvFile         // Files with environment variables
 	WorkingDir       string            // Working directory
 	LogFile          string            // Path to log file
 	KillTimeout      int               // Kill timeout in seconds
 	KillSignal       string            // Kill signal name
 	KillMode         string            // Kill mode (systemd only)
+	Type             string            // Service type (systemd only)
+	NotifyAccess     string            // Access to notification socket (systemd only)
+	PIDFile          string            // Path to PID file of forking service (systemd only)
+	WatchdogSec      int               // Watchdog timeout in seconds (systemd only)
 	ReloadSignal     string            // Reload signal name (systemd only)
 	Count            int               // Exec count
 	RespawnInterval  int               // Respawn interval in seconds
@@ -75,6 +104,8 @@ type ServiceOptions struct {
 	LimitFile        int               // Descriptors limit
 	LimitMemlock     int               // Max locked memory limit
 	Resources        *Resources        // Resources limits (systemd only)
+	Security         *Security         // Sandboxing options (systemd only)
+	Secrets          []*Secret         // Files with secrets
 	IsRespawnEnabled bool              // Respawn enabled flag
 }
 
@@ -100,18 +131,26 @@ type Resources struct {
 }
 
 type Application struct {
-	Name               string     // Name of application
-	Services           []*Service // List of services in application
-	User               string     // Working user
-	Group              string     // Working group
-	StartLevel         int        // Start level
-	StopLevel          int        // Stop level
-	StartDevice        string     // Start on device activation
-	Depends            []string   // Dependencies
-	WorkingDir         string     // Working directory
-	ReloadHelperPath   string     // Path to reload helper (will be set by exporter)
-	ProcVersion        int        // Proc version 1/2
-	StrongDependencies bool       // Use strong dependencies
+	Name               string      // Name of application
+	Services           []*Service  // List of services in application
+	Schedules          []*Schedule // List of scheduled jobs
+	Tasks              []*Task     // List of tasks executed before services start
+	User               string      // Working user
+	Group              string      // Working group
+	StartLevel         int         // Start level
+	StopLevel          int         // Stop level
+	StartDevice        string      // Start on device activation
+	Depends            []string    // Dependencies
+	WorkingDir         string      // Working directory
+	ReloadHelperPath   string      // Path to reload helper (will be set by exporter)
+	ProcVersion        int         // Proc version 1/2
+	StrongDependencies bool        // Use strong dependencies
+	Profile            string      // Name of applied profile
+	Profiles           []string    // Names of all profiles defined in procfile
+	Logrotate          *Logrotate  // Rotation policy for logs
+
+	source       *source       // Positions of properties in procfile
+	deferredErrs errors.Errors // Errors found while parsing which are reported by validation
 }
 
 // ////////////////////////////////////////////////////////////////////////////////// //
@@ -132,17 +171,30 @@ func Read(path string, config *Config) (*Application, error) {
 		return nil, err
 	}
 
-	switch determineProcVersion(data) {
+	var app *Application
 
+	switch determineProcVersion(data) {
 	case 1:
-		return parseV1Procfile(data, config)
-
+		app, err = parseV1Procfile(data, config)
 	case 2:
-		return parseV2Procfile(data, config)
+		app, err = readV2Procfile(path, data, config)
+	default:
+		return nil, fmt.Errorf("Can't determine version for procfile %s", path)
+	}
 
+	if err != nil {
+		e, ok := err.(*Error)
+
+		if ok && e.Pos.File == "" {
+			e.Pos.File = path
+		}
+
+		return nil, err
 	}
 
-	return nil, fmt.Errorf("Can't determine version for procfile %s", path)
+	app.source.file = path
+
+	return app, nil
 }
 
 // ////////////////////////////////////////////////////////////////////////////////// //
@@ -151,25 +203,141 @@ func Read(path string, config *Config) (*Application, error) {
 func (a *Application) Validate() []error {
 	var errs errors.Bundle
 
-	errs.Add(checkRunLevel(a.StartLevel))
-	errs.Add(checkRunLevel(a.StopLevel))
+	errs.Add(newError(RULE_INVALID_VALUE, "start_on_runlevel", checkRunLevel(a.StartLevel)))
+	errs.Add(newError(RULE_INVALID_VALUE, "stop_on_runlevel", checkRunLevel(a.StopLevel)))
 	errs.Add(checkDependencies(a.Depends))
 
 	if a.WorkingDir == "" {
-		errs.Add(fmt.Errorf("Application working dir can't be empty"))
+		errs.Add(&Error{Rule: RULE_INVALID_VALUE, Path: "working_directory", Message: "Application working dir can't be empty"})
 	}
 
+	errs.Add(newError(RULE_INVALID_VALUE, "user", checkUserName("User", a.User)))
+	errs.Add(newError(RULE_INVALID_VALUE, "group", checkUserName("Group", a.Group)))
+
 	if a.StartDevice != "" && !regexp.MustCompile(REGEXP_NET_DEVICE_CHECK).MatchString(a.StartDevice) {
-		errs.Add(fmt.Errorf("Name of device (%s) is not a valid", a.StartDevice))
+		errs.Add(&Error{
+			Rule:    RULE_INVALID_VALUE,
+			Path:    "start_on_device",
+			Message: fmt.Sprintf("Name of device (%s) is not a valid", a.StartDevice),
+		})
 	}
 
+	if a.Logrotate != nil {
+		errs.Add(a.Logrotate.Validate())
+	}
+
+	a.source.annotate("", errs.All()...)
+
 	for _, service := range a.Services {
 		errs.Add(service.Validate())
 	}
 
+	errs.Add(a.checkDependencyCycles())
+
+	for _, schedule := range a.Schedules {
+		errs.Add(schedule.Validate())
+	}
+
+	for _, task := range a.Tasks {
+		errs.Add(task.Validate())
+	}
+
+	a.source.annotate("", a.deferredErrs...)
+	errs.Add(a.deferredErrs)
+
 	return errs.All()
 }
 
+// ValidateUsers checks that users and groups of application, all services,
+// scheduled jobs and tasks exist on the host
+func (a *Application) ValidateUsers() []error {
+	var errs []error
+
+	appErrs := checkUserExist(a.User, a.Group)
+	a.source.annotate("", appErrs...)
+	errs = append(errs, appErrs...)
+
+	for _, service := range a.Services {
+		serviceErrs := checkUserExist(service.Options.User, service.Options.Group)
+		a.source.annotate(service.Name, serviceErrs...)
+		errs = append(errs, serviceErrs...)
+	}
+
+	for _, schedule := range a.Schedules {
+		scheduleErrs := checkUserExist(schedule.Options.User, schedule.Options.Group)
+		schedule.annotate(scheduleErrs...)
+		errs = append(errs, scheduleErrs...)
+	}
+
+	for _, task := range a.Tasks {
+		taskErrs := checkUserExist(task.Options.User, task.Options.Group)
+		task.annotate(taskErrs...)
+		errs = append(errs, taskErrs...)
+	}
+
+	return errs
+}
+
+// GetService returns service with given name
+func (a *Application) GetService(name string) *Service {
+	for _, service := range a.Services {
+		if service.Name == name {
+			return service
+		}
+	}
+
+	return nil
+}
+
+// GetSchedule returns scheduled job with given name
+func (a *Application) GetSchedule(name string) *Schedule {
+	for _, schedule := range a.Schedules {
+		if schedule.Name == name {
+			return schedule
+		}
+	}
+
+	return nil
+}
+
+// GetTask returns task with given name
+func (a *Application) GetTask(name string) *Task {
+	for _, task := range a.Tasks {
+		if task.Name == name {
+			return task
+		}
+	}
+
+	return nil
+}
+
+// GetServiceByUnit returns service with given name or service which instance
+// has given name (i.e. web1 for service web with count 2)
+func (a *Application) GetServiceByUnit(name string) *Service {
+	for _, service := range a.Services {
+		if service.Name == name {
+			return service
+		}
+
+		if service.Options == nil || !strings.HasPrefix(name, service.Name) {
+			continue
+		}
+
+		for i := 1; i <= service.Options.Count; i++ {
+			if name == service.Name+strconv.Itoa(i) {
+				return service
+			}
+		}
+	}
+
+	return nil
+}
+
+// HasTasks returns true if application has tasks executed before services start
+func (a *Application) HasTasks() bool {
+	return len(a.Tasks) != 0
+}
+
 // IsReloadSignalSet returns true if any service contains reload signal
 func (a *Application) IsReloadSignalSet() bool {
 	for _, service := range a.Services {
@@ -186,10 +354,55 @@ func (s *Service) Validate() *errors.Bundle {
 	var errs errors.Bundle
 
 	if !regexp.MustCompile(REGEXP_NAME_CHECK).MatchString(s.Name) {
-		errs.Add(fmt.Errorf("Service name %s is misformatted and can't be accepted", s.Name))
+		errs.Add(&Error{Rule: RULE_INVALID_NAME, Message: fmt.Sprintf("Service name %s is misformatted and can't be accepted", s.Name)})
 	}
 
 	errs.Add(s.Options.Validate())
+	errs.Add(s.checkDependsOn())
+
+	// Health check is validated for the first instance, because ${PORT} and
+	// ${INSTANCE} are resolved only on export
+	if s.HealthCheck != nil {
+		index := ""
+
+		if s.Options != nil && s.Options.Count > 0 {
+			index = "1"
+		}
+
+		errs.Add(s.WithInstance(index).HealthCheck.Validate())
+	}
+
+	if s.Sockets != nil {
+		errs.Add(s.Sockets.Validate())
+
+		if s.Options.Count > 1 {
+			errs.Add(&Error{
+				Rule:    RULE_INVALID_VALUE,
+				Path:    "sockets",
+				Message: "sockets can't be used for command with multiple instances",
+			})
+		}
+
+		if s.Sockets.Accept && s.HealthCheck != nil {
+			errs.Add(&Error{
+				Rule:    RULE_INVALID_VALUE,
+				Path:    "sockets.accept",
+				Message: "health check can't be used for command started for every connection",
+			})
+		}
+	}
+
+	if s.Port < 0 || s.Port+max(s.Options.Count, 1)-1 > MAX_PORT {
+		errs.Add(&Error{
+			Rule:    RULE_INVALID_VALUE,
+			Path:    "port",
+			Message: fmt.Sprintf("ports of all instances must be in range 1-%d", MAX_PORT),
+		})
+	}
+
+	if s.Application != nil {
+		s.Application.source.annotate(s.Name, errs.All()...)
+	}
 
 	return &errs
 }
@@ -198,75 +411,109 @@ func (s *Service) Validate() *errors.Bundle {
 func (so *ServiceOptions) Validate() *errors.Bundle {
 	var errs errors.Bundle
 
-	errs.Add(checkPath(so.WorkingDir))
+	errs.Add(newError(RULE_INSECURE_PATH, "working_directory", checkPath(so.WorkingDir)))
+	errs.Add(newError(RULE_INVALID_VALUE, "user", checkUserName("User", so.User)))
+	errs.Add(newError(RULE_INVALID_VALUE, "group", checkUserName("Group", so.Group)))
 
 	if so.IsCustomLogEnabled() {
-		errs.Add(checkPath(so.FullLogPath()))
+		errs.Add(newError(RULE_INSECURE_PATH, "log", checkPath(so.FullLogPath())))
 	}
 
-	if so.IsEnvFileSet() {
-		errs.Add(checkPath(so.FullEnvFilePath()))
+	for _, file := range so.EnvFiles {
+		errs.Add(newError(RULE_INSECURE_PATH, "env_file", checkPath(so.FullEnvFilePath(file))))
 	}
 
-	for envName, envVal := range so.Env {
-		errs.Add(checkEnv(envName, envVal))
+	for _, envName := range slices.Sorted(maps.Keys(so.Env)) {
+		errs.Add(newError(RULE_INVALID_ENV, "env."+envName, checkEnv(envName, so.Env[envName])))
 	}
 
 	if so.Count < 0 {
-		errs.Add(fmt.Errorf("Property \"count\" must be greater or equal 0"))
+		errs.Add(&Error{Rule: RULE_INVALID_VALUE, Path: "count", Message: "must be greater or equal 0"})
 	}
 
 	if so.KillTimeout < 0 {
-		errs.Add(fmt.Errorf("Property \"kill_timeout\" must be greater or equal 0"))
+		errs.Add(&Error{Rule: RULE_INVALID_VALUE, Path: "kill_timeout", Message: "must be greater or equal 0"})
 	}
 
 	if so.LimitFile < 0 {
-		errs.Add(fmt.Errorf("Property \"nofile\" must be greater or equal 0"))
+		errs.Add(&Error{Rule: RULE_INVALID_VALUE, Path: "limits.nofile", Message: "must be greater or equal 0"})
 	}
 
 	if so.LimitProc < 0 {
-		errs.Add(fmt.Errorf("Property \"nproc\" must be greater or equal 0"))
+		errs.Add(&Error{Rule: RULE_INVALID_VALUE, Path: "limits.nproc", Message: "must be greater or equal 0"})
 	}
 
 	if so.RespawnCount < 0 {
-		errs.Add(fmt.Errorf("Property \"respawn:count\" must be greater or equal 0"))
+		errs.Add(&Error{Rule: RULE_INVALID_VALUE, Path: "respawn.count", Message: "must be greater or equal 0"})
 	}
 
 	if so.RespawnInterval < 0 {
-		errs.Add(fmt.Errorf("Property \"respawn:interval\" must be greater or equal 0"))
+		errs.Add(&Error{Rule: RULE_INVALID_VALUE, Path: "respawn.interval", Message: "must be greater or equal 0"})
 	}
 
 	if so.RespawnDelay < 0 {
-		errs.Add(fmt.Errorf("Property \"respawn:delay\" must be greater or equal 0"))
+		errs.Add(&Error{Rule: RULE_INVALID_VALUE, Path: "respawn.delay", Message: "must be greater or equal 0"})
 	}
 
 	if so.KillMode != "" && !slices.Contains([]string{"control-group", "process", "mixed", "none"}, so.KillMode) {
-		errs.Add(fmt.Errorf("Property \"kill_mode\" must contains 'control-group', 'process', 'mixed' or 'none'"))
+		errs.Add(&Error{Rule: RULE_INVALID_VALUE, Path: "kill_mode", Message: "must contain 'control-group', 'process', 'mixed' or 'none'"})
+	}
+
+	if so.Type != "" && !slices.Contains([]string{"simple", "exec", "notify", "forking"}, so.Type) {
+		errs.Add(&Error{Rule: RULE_INVALID_VALUE, Path: "type", Message: "must contain 'simple', 'exec', 'notify' or 'forking'"})
+	}
+
+	if so.NotifyAccess != "" && !slices.Contains([]string{"none", "main", "exec", "all"}, so.NotifyAccess) {
+		errs.Add(&Error{Rule: RULE_INVALID_VALUE, Path: "notify_access", Message: "must contain 'none', 'main', 'exec' or 'all'"})
+	}
+
+	if so.WatchdogSec < 0 {
+		errs.Add(&Error{Rule: RULE_INVALID_VALUE, Path: "watchdog_sec", Message: "must be greater or equal 0"})
+	}
+
+	if so.WatchdogSec > 0 && so.Type != "notify" {
+		errs.Add(&Error{Rule: RULE_INVALID_VALUE, Path: "watchdog_sec", Message: "watchdog can be used only for service with 'notify' type"})
+	}
+
+	if so.PIDFile != "" && so.Type != "forking" {
+		errs.Add(&Error{Rule: RULE_INVALID_VALUE, Path: "pid_file", Message: "PID file can be used only for service with 'forking' type"})
+	}
+
+	errs.Add(newError(RULE_INSECURE_PATH, "pid_file", checkPath(so.PIDFile)))
+
+	if so.Security != nil {
+		errs.Add(so.Security.Validate())
+	}
+
+	for _, secret := range so.Secrets {
+		errs.Add(secret.Validate())
 	}
 
 	if so.Resources != nil {
 		if so.Resources.CPUWeight < 0 || so.Resources.CPUWeight > 10000 {
-			errs.Add(fmt.Errorf("Property \"resources:cpu_weight\" must be greater or equal 0 and less or equal 10000"))
+			errs.Add(&Error{Rule: RULE_INVALID_VALUE, Path: "resources.cpu_weight", Message: "must be greater or equal 0 and less or equal 10000"})
 		}
 
 		if so.Resources.CPUAffinity != "" && !regexp.MustCompile(REGEXP_CPU_AFFINITY_CHECK).MatchString(so.Resources.CPUAffinity) {
-			errs.Add(fmt.Errorf("Property \"resources:cpu_affinity\" contains misformatted value"))
+			errs.Add(&Error{Rule: RULE_INVALID_VALUE, Path: "resources.cpu_affinity", Message: "value is misformatted"})
+		} else if so.Resources.CPUAffinity != "" && countAffinityCPUs(so.Resources.CPUAffinity) == 0 {
+			errs.Add(&Error{Rule: RULE_INVALID_VALUE, Path: "resources.cpu_affinity", Message: fmt.Sprintf("must contain CPU numbers or ranges of CPU numbers in range 0-%d", MAX_CPU)})
 		}
 
 		if so.Resources.StartupCPUWeight < 0 || so.Resources.StartupCPUWeight > 10000 {
-			errs.Add(fmt.Errorf("Property \"resources:startup_cpu_weight\" must be greater or equal 0 and less or equal 10000"))
+			errs.Add(&Error{Rule: RULE_INVALID_VALUE, Path: "resources.startup_cpu_weight", Message: "must be greater or equal 0 and less or equal 10000"})
 		}
 
 		if so.Resources.CPUQuota < 0 {
-			errs.Add(fmt.Errorf("Property \"resources:cpu_quota\" must be greater than 0"))
+			errs.Add(&Error{Rule: RULE_INVALID_VALUE, Path: "resources.cpu_quota", Message: "must be greater than 0"})
 		}
 
 		if so.Resources.IOWeight < 0 || so.Resources.IOWeight > 10000 {
-			errs.Add(fmt.Errorf("Property \"resources:io_weight\" must be greater or equal 0 and less or equal 10000"))
+			errs.Add(&Error{Rule: RULE_INVALID_VALUE, Path: "resources.io_weight", Message: "must be greater or equal 0 and less or equal 10000"})
 		}
 
 		if so.Resources.StartupIOWeight < 0 || so.Resources.StartupIOWeight > 10000 {
-			errs.Add(fmt.Errorf("Property \"resources:startup_io_weight\" must be greater or equal 0 and less or equal 10000"))
+			errs.Add(&Error{Rule: RULE_INVALID_VALUE, Path: "resources.startup_io_weight", Message: "must be greater or equal 0 and less or equal 10000"})
 		}
 	}
 
@@ -283,22 +530,36 @@ func (s *Service) HasPostCmd() bool {
 	return s.PostCmd != ""
 }
 
-// GetCommandExecWithEnv return full command exec with env vars setting
-func (s *Service) GetCommandExec(command string) string {
-	var result = "exec "
-
-	if s.Options.IsEnvSet() || s.Options.IsEnvFileSet() {
-		result += "env "
+// GetUser returns name of user for running service
+func (s *Service) GetUser() string {
+	if s.Options != nil && s.Options.User != "" {
+		return s.Options.User
+	}
 
-		if s.Options.IsEnvFileSet() {
-			result += "$(cat " + s.Options.FullEnvFilePath() + " 2>/dev/null | xargs) "
-		}
+	return s.Application.User
+}
 
-		if s.Options.IsEnvSet() {
-			result += s.Options.EnvString() + " "
-		}
+// GetGroup returns name of group for running service
+func (s *Service) GetGroup() string {
+	if s.Options != nil && s.Options.Group != "" {
+		return s.Options.Group
 	}
 
+	return s.Application.Group
+}
+
+// IsDirectExec returns true if command is defined as list of arguments and
+// can be executed without shell (env vars which refer to other variables and
+// post command, which is executed after command, require shell)
+func (s *Service) IsDirectExec() bool {
+	return len(s.CmdArgs) != 0 && !s.HasPostCmd() && (s.Options == nil || !s.Options.IsShellEnvSet())
+}
+
+// GetCommandExec return full command exec with log redirection (environment
+// variables are set by init system)
+func (s *Service) GetCommandExec(command string) string {
+	var result = "exec "
+
 	switch command {
 	case "pre":
 		result += s.PreCmd
@@ -330,9 +591,21 @@ func (so *ServiceOptions) IsEnvSet() bool {
 	return len(so.Env) != 0
 }
 
-// IsEnvFileSet returns true if service have file with env vars
+// IsShellEnvSet returns true if service have env vars which values must be
+// expanded by shell
+func (so *ServiceOptions) IsShellEnvSet() bool {
+	for _, v := range so.Env {
+		if IsShellEnvValue(v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsEnvFileSet returns true if service have files with env vars
 func (so *ServiceOptions) IsEnvFileSet() bool {
-	return so.EnvFile != ""
+	return len(so.EnvFiles) != 0
 }
 
 // IsFileLimitSet returns true if descriptors limit is set
@@ -360,6 +633,20 @@ func (so *ServiceOptions) IsKillModeSet() bool {
 	return so.KillMode != ""
 }
 
+// GetType returns type of service (simple by default)
+func (so *ServiceOptions) GetType() string {
+	if so.Type == "" {
+		return "simple"
+	}
+
+	return so.Type
+}
+
+// IsNotifyType returns true if service notifies init system about its state
+func (so *ServiceOptions) IsNotifyType() bool {
+	return so.Type == "notify"
+}
+
 // IsResourcesSet returns true if resources limits are set
 func (so *ServiceOptions) IsResourcesSet() bool {
 	return so.Resources != nil
@@ -387,6 +674,20 @@ func (so *ServiceOptions) EnvString() string {
 	return strings.Join(clauses, " ")
 }
 
+// ShellEnvString returns export statements for env vars which values must be
+// expanded by shell
+func (so *ServiceOptions) ShellEnvString() string {
+	var result []string
+
+	for _, name := range slices.Sorted(maps.Keys(so.Env)) {
+		if IsShellEnvValue(so.Env[name]) {
+			result = append(result, "export "+name+"="+so.Env[name])
+		}
+	}
+
+	return strings.Join(result, "\n")
+}
+
 // FullLogPath return absolute path to service log
 func (so *ServiceOptions) FullLogPath() string {
 	if strings.HasPrefix(so.LogFile, "/") {
@@ -396,70 +697,101 @@ func (so *ServiceOptions) FullLogPath() string {
 	return so.WorkingDir + "/" + so.LogFile
 }
 
-// FullEnvFilePath return absolute path to file with env vars
-func (so *ServiceOptions) FullEnvFilePath() string {
-	if strings.HasPrefix(so.EnvFile, "/") {
-		return so.EnvFile
+// FullEnvFilePath return absolute path to given file with env vars
+func (so *ServiceOptions) FullEnvFilePath(file EnvFile) string {
+	if strings.HasPrefix(file.Path, "/") {
+		return file.Path
 	}
 
-	return so.WorkingDir + "/" + so.EnvFile
+	return so.WorkingDir + "/" + file.Path
 }
 
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 // parseCommand parse shell command and extract command body, output redirection
 // and environment variables
-func parseCommand(command string) (string, string, map[string]string) {
-	var (
-		env map[string]string
-		cmd []string
-		log string
+func parseCommand(command string) (string, string, map[string]string, error) {
+	tokens, err := Tokenize(command)
 
-		isEnv bool
-		isLog bool
-	)
+	if err != nil {
+		return "", "", nil, err
+	}
 
-	cmdSlice := strings.Fields(command)
+	var (
+		env   map[string]string
+		cmd   []string
+		log   string
+		index int
+	)
 
-	for index, token := range cmdSlice {
-		if strings.TrimSpace(token) == "" {
-			continue
-		}
+	// Environment variables can be defined by assignments before command
+	// or by env command
+	if len(tokens) > 1 && tokens[0].IsWord("env") && tokens[1].IsAssignment() {
+		index++
+	}
 
-		if strings.HasPrefix(token, "env") {
+	for ; index < len(tokens) && tokens[index].IsAssignment(); index++ {
+		if env == nil {
 			env = make(map[string]string)
-			isEnv = true
-			continue
 		}
 
-		if index == 0 && strings.Contains(token, "=") {
-			env = make(map[string]string)
-			isEnv = true
-		}
+		name, value, _ := strings.Cut(tokens[index].Raw, "=")
+		env[name] = value
+	}
 
-		if isEnv {
-			if strings.Contains(token, "=") {
-				env[strutil.ReadField(token, 0, false, '=')] = strutil.ReadField(token, 1, false, '=')
-				continue
-			} else {
-				isEnv = false
-			}
+	// Parts of original command which are kept in command body
+	var parts [][2]int
+
+	keep := func(start, end int, isJoined bool) {
+		if isJoined && len(parts) != 0 {
+			parts[len(parts)-1][1] = end
+		} else {
+			parts = append(parts, [2]int{start, end})
 		}
+	}
 
-		if strings.Contains(token, ">>") {
-			isLog = true
+	for isJoined := false; index < len(tokens); index++ {
+		token := tokens[index]
+
+		if token.Kind != TOKEN_REDIRECT {
+			keep(token.Pos, token.End, isJoined)
+			isJoined = true
 			continue
 		}
 
-		if isLog {
-			log = token
-			break
+		target := tokens[index+1]
+		index++
+
+		switch {
+		case log == "" && isLogRedirect(token):
+			log = target.Value
+			isJoined = false
+
+		// Stderr is always written to log with stdout
+		case log != "" && token.Value == "2>&" && target.Value == "1":
+			isJoined = false
+
+		default:
+			keep(token.Pos, target.End, isJoined)
+			isJoined = true
 		}
+	}
+
+	for _, part := range parts {
+		cmd = append(cmd, command[part[0]:part[1]])
+	}
 
-		cmd = append(cmd, token)
+	return strings.Join(cmd, " "), log, env, nil
+}
+
+// isLogRedirect returns true if token is redirection of output to log file
+func isLogRedirect(token Token) bool {
+	switch token.Value {
+	case ">>", "1>>", "&>>":
+		return true
 	}
 
-	return strings.Join(cmd, " "), log, env
+	return false
 }
 
 // determineProcVersion process procfile data and return procfile version
@@ -489,8 +821,16 @@ func mergeServiceOptions(dst, src *ServiceOptions) {
 		mergeStringMaps(dst.Env, src.Env)
 	}
 
-	if dst.EnvFile == "" {
-		dst.EnvFile = src.EnvFile
+	if len(dst.EnvFiles) == 0 {
+		dst.EnvFiles = slices.Clone(src.EnvFiles)
+	}
+
+	if dst.User == "" {
+		dst.User = src.User
+	}
+
+	if dst.Group == "" {
+		dst.Group = src.Group
 	}
 
 	if dst.WorkingDir == "" {
@@ -528,6 +868,30 @@ func mergeServiceOptions(dst, src *ServiceOptions) {
 	if dst.LimitMemlock == 0 {
 		dst.LimitMemlock = src.LimitMemlock
 	}
+
+	if dst.Type == "" {
+		dst.Type = src.Type
+	}
+
+	if dst.NotifyAccess == "" {
+		dst.NotifyAccess = src.NotifyAccess
+	}
+
+	if dst.PIDFile == "" {
+		dst.PIDFile = src.PIDFile
+	}
+
+	if dst.WatchdogSec == 0 {
+		dst.WatchdogSec = src.WatchdogSec
+	}
+
+	if src.Security != nil {
+		dst.Security = mergeSecurity(dst.Security, src.Security)
+	}
+
+	if src.IsSecretsSet() {
+		dst.Secrets = mergeSecrets(dst.Secrets, src.Secrets)
+	}
 }
 
 // configureDefaults set options default values
@@ -548,6 +912,17 @@ func configureDefaults(serviceOptions *ServiceOptions, config *Config) {
 		serviceOptions.IsRespawnEnabled = true
 	}
 
+	if config.SecurityPreset != "" && config.SecurityPreset != SECURITY_PRESET_NONE {
+		switch {
+		case serviceOptions.Security == nil:
+			serviceOptions.Security = &Security{Preset: config.SecurityPreset}
+		case serviceOptions.Security.Preset == "":
+			security := *serviceOptions.Security
+			security.Preset = config.SecurityPreset
+			serviceOptions.Security = &security
+		}
+	}
+
 	if serviceOptions.IsRespawnEnabled {
 		if serviceOptions.RespawnCount == 0 {
 			serviceOptions.RespawnCount = config.RespawnCount
@@ -585,6 +960,36 @@ func checkPath(value string) error {
 	return nil
 }
 
+// checkUserName checks name of user or group and return error if name is misformatted
+func checkUserName(kind, name string) error {
+	if name == "" || regexp.MustCompile(REGEXP_USER_CHECK).MatchString(name) {
+		return nil
+	}
+
+	return fmt.Errorf("%s name %s is misformatted and can't be accepted", kind, name)
+}
+
+// checkUserExist checks that given user and group exist on the host
+func checkUserExist(user, group string) []error {
+	var errs []error
+
+	if user != "" && !system.IsUserExist(user) {
+		errs = append(errs, &Error{Rule: RULE_INVALID_VALUE, Path: "user", Message: fmt.Sprintf("User %s doesn't exist", user)})
+	}
+
+	if group != "" && !system.IsGroupExist(group) {
+		errs = append(errs, &Error{Rule: RULE_INVALID_VALUE, Path: "group", Message: fmt.Sprintf("Group %s doesn't exist", group)})
+	}
+
+	return errs
+}
+
+// IsShellEnvValue returns true if env variable value refers to other variables
+// and must be expanded by shell
+func IsShellEnvValue(value string) bool {
+	return strings.Contains(value, "$")
+}
+
 // checkEnv checks given env variable and return error if name or value is insecure
 func checkEnv(name, value string) error {
 	if name == "" {
@@ -637,7 +1042,90 @@ func checkDependencies(deps []string) *errors.Bundle {
 
 	for _, dep := range deps {
 		if !regexp.MustCompile(REGEXP_NAME_CHECK).MatchString(dep) {
-			errs.Add(fmt.Errorf("Dependency name %s is misformatted and can't be accepted", dep))
+			errs.Add(&Error{
+				Rule:    RULE_INVALID_NAME,
+				Path:    "depends",
+				Message: fmt.Sprintf("Dependency name %s is misformatted and can't be accepted", dep),
+			})
+		}
+	}
+
+	return &errs
+}
+
+// checkDependsOn checks that all services from depends_on exist in application
+func (s *Service) checkDependsOn() *errors.Bundle {
+	var errs errors.Bundle
+
+	for _, dep := range s.DependsOn {
+		switch {
+		case dep == s.Name:
+			errs.Add(&Error{Rule: RULE_INVALID_VALUE, Path: "depends_on", Message: "service can't depend on itself"})
+		case s.Application != nil && s.Application.GetService(dep) == nil:
+			errs.Add(&Error{Rule: RULE_INVALID_VALUE, Path: "depends_on", Message: fmt.Sprintf("unknown service %s", dep)})
+		}
+	}
+
+	return &errs
+}
+
+// checkDependencyCycles checks that dependencies between services don't
+// contain cycles
+func (a *Application) checkDependencyCycles() *errors.Bundle {
+	var errs errors.Bundle
+
+	// 0 - not visited, 1 - in current chain, 2 - checked
+	state := make(map[string]int)
+	reported := make(map[string]bool)
+
+	var visit func(service *Service, chain []string)
+
+	visit = func(service *Service, chain []string) {
+		state[service.Name] = 1
+		chain = append(chain, service.Name)
+
+		for _, dep := range service.DependsOn {
+			depService := a.GetService(dep)
+
+			switch {
+			case depService == nil || dep == service.Name:
+				continue
+
+			case state[dep] == 1:
+				cycle := append(chain[slices.Index(chain, dep):], dep)
+
+				if reported[dep] {
+					continue
+				}
+
+				for _, name := range cycle {
+					reported[name] = true
+				}
+
+				err := &Error{
+					Rule:    RULE_INVALID_VALUE,
+					Path:    "depends_on",
+					Message: fmt.Sprintf("Dependency cycle detected (%s)", strings.Join(cycle, " → ")),
+				}
+
+				a.source.annotate(service.Name, err)
+				errs.Add(err)
+
+			case state[dep] == 0:
+				visit(depService, chain)
+			}
+		}
+
+		state[service.Name] = 2
+	}
+
+	services := slices.SortedFunc(slices.Values(a.Services), func(s1, s2 *Service) int {
+		return strings.Compare(s1.Name, s2.Name)
+	})
+
+	for _, service := range services {
+		if state[service.Name] == 0 {
+			visit(service, nil)
 		}
 	}
 
@@ -650,6 +1138,14 @@ func addCrossLink(app *Application) {
 	for _, service := range app.Services {
 		service.Application = app
 	}
+
+	for _, schedule := range app.Schedules {
+		schedule.Application = app
+	}
+
+	for _, task := range app.Tasks {
+		task.Application = app
+	}
 }
 
 // isUnquotedValue returns true if given value is unquoted
@@ -7,6 +7,9 @@ package procfile
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 import (
+	"os"
+	"path/filepath"
+	"sort"
 	"testing"
 
 	. "github.com/essentialkaos/check"
@@ -74,6 +77,1833 @@ func (s *ProcfileSuite) TestProcV1Fuzz(c *C) {
 	c.Assert(err, NotNil)
 }
 
+func (s *ProcfileSuite) TestTokenize(c *C) {
+	tokens, err := Tokenize(`A="x y" echo 'a && b' "c \"d\"" e\ f $(date +%s) ${X:-a b} >>app.log 2>&1; g | h # comment`)
+
+	c.Assert(err, IsNil)
+
+	var values []string
+
+	for _, token := range tokens {
+		values = append(values, token.Value)
+	}
+
+	c.Assert(values, DeepEquals, []string{
+		"A=x y", "echo", "a && b", `c "d"`, "e f", "$(date +%s)", "${X:-a b}",
+		">>", "app.log", "2>&", "1", ";", "g", "|", "h",
+	})
+
+	c.Assert(tokens[0].Raw, Equals, `A="x y"`)
+	c.Assert(tokens[0].IsAssignment(), Equals, true)
+	c.Assert(tokens[7].Kind, Equals, TOKEN_REDIRECT)
+	c.Assert(tokens[11].IsOperator(";"), Equals, true)
+
+	for _, cmd := range []string{`echo "a`, `echo 'a`, "echo `a", "echo $(a", "echo >", "echo > ;"} {
+		_, err = Tokenize(cmd)
+		c.Assert(err, NotNil, Commentf("Command: %s", cmd))
+	}
+
+	cmd, log, env, _ := parseCommand(`env A=1 B="x y" /bin/app --name "a && b" >> log/app.log 2>&1`)
+
+	c.Assert(cmd, Equals, `/bin/app --name "a && b"`)
+	c.Assert(log, Equals, "log/app.log")
+	c.Assert(env, DeepEquals, map[string]string{"A": "1", "B": `"x y"`})
+
+	cmd, log, _, _ = parseCommand(`/bin/app 2>>error.log >> app.log | tee out`)
+
+	c.Assert(cmd, Equals, `/bin/app 2>>error.log | tee out`)
+	c.Assert(log, Equals, "app.log")
+
+	cmd, _, env, _ = parseCommand(`envoy -c config.yml`)
+
+	c.Assert(cmd, Equals, `envoy -c config.yml`)
+	c.Assert(env, IsNil)
+
+	service, err := parseV1Line(`web: cd "/srv/my app" && echo "pre && pre" && /bin/app; /bin/other >> app.log && echo post`)
+
+	c.Assert(err, IsNil)
+	c.Assert(service.Options.WorkingDir, Equals, "/srv/my app")
+	c.Assert(service.PreCmd, Equals, `echo "pre && pre"`)
+	c.Assert(service.Cmd, Equals, `/bin/app; /bin/other`)
+	c.Assert(service.Options.LogFile, Equals, "app.log")
+	c.Assert(service.PostCmd, Equals, `echo post`)
+
+	_, err = parseV1Line(`web: /bin/app && && /bin/other`)
+	c.Assert(err, NotNil)
+	_, err = parseV1Line(`web: /bin/app "unterminated`)
+	c.Assert(err, NotNil)
+}
+
+func (s *ProcfileSuite) TestProcV2StrictParsing(c *C) {
+	app, err := Read("../testdata/procfile_v2_strict", s.Config)
+
+	c.Assert(err, IsNil)
+	c.Assert(app, NotNil)
+	c.Assert(app.Validate(), HasLen, 0)
+
+	app, err = Read("../testdata/procfile_v2_strict", &Config{
+		Name: "test-app", WorkingDir: "/tmp", IsStrict: true,
+	})
+
+	c.Assert(err, IsNil)
+	c.Assert(app, NotNil)
+
+	errs := app.Validate()
+
+	c.Assert(errs, HasLen, 7)
+	c.Assert(errs[0].Error(), Equals, `../testdata/procfile_v2_strict:5:1: limts: unknown property (did you mean "limits"?)`)
+	c.Assert(errs[1].Error(), Equals, `../testdata/procfile_v2_strict:24:5: commands.my_another_tail_cmd.foo_bar_baz: unknown property`)
+	c.Assert(errs[2].Error(), Equals, `../testdata/procfile_v2_strict:23:7: commands.my_another_tail_cmd.respawn.intreval: unknown property (did you mean "interval"?)`)
+	c.Assert(errs[3].Error(), Equals, `../testdata/procfile_v2_strict:11:5: commands.my_tail_cmd.kill_timout: unknown property (did you mean "kill_timeout"?)`)
+	c.Assert(errs[4].Error(), Equals, `../testdata/procfile_v2_strict:12:5: commands.my_tail_cmd.respwan: unknown property (did you mean "respawn"?)`)
+	c.Assert(errs[5].Error(), Equals, `../testdata/procfile_v2_strict:15:7: commands.my_tail_cmd.limits.nofiles: unknown property (did you mean "nofile"?)`)
+	c.Assert(errs[6].Error(), Equals, `../testdata/procfile_v2_strict:17:7: commands.my_tail_cmd.resources.cpu_wieght: unknown property (did you mean "cpu_weight"?)`)
+
+	pErr, ok := errs[5].(*Error)
+
+	c.Assert(ok, Equals, true)
+	c.Assert(pErr.Service, Equals, "")
+	c.Assert(pErr.Pos, DeepEquals, Position{"../testdata/procfile_v2_strict", 15, 7})
+
+	app, err = Read("../testdata/procfile_v2", &Config{
+		Name: "test-app", WorkingDir: "/tmp", IsStrict: true,
+	})
+
+	c.Assert(err, IsNil)
+	c.Assert(app.Validate(), HasLen, 0)
+}
+
+func (s *ProcfileSuite) TestErrorPositions(c *C) {
+	_, err := parseV2Procfile([]byte("version: 2\ncommands:\n  web:\n    command: /bin/app\n    limits:\n      nofile: abc\n"), s.Config)
+
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, "6:7: commands.web.limits.nofile: expected integer")
+
+	_, err = parseV2Procfile([]byte("version: 2\nstart_on_runlevel: abc\ncommands:\n  web:\n    command: /bin/app\n"), s.Config)
+
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, "2:1: start_on_runlevel: expected integer")
+
+	_, err = parseV2Procfile([]byte("version: 2\ncommands:\n  web:\n command: /bin/app\n   count: 1"), s.Config)
+
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, "3: did not find expected key")
+
+	_, err = parseV2Procfile([]byte("version: 2\ncommands:\n  web:\n    command: /bin/app --name 'my app\n"), s.Config)
+
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, "4:5: commands.web.command: can't parse command: Unterminated single quote at 17")
+
+	_, err = parseV2Procfile([]byte("version: 2\ncommands:\n  web:\n    command: /bin/app\n    pre: echo \"start\n"), s.Config)
+
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, "5:5: commands.web.pre: can't parse command: Unterminated double quote at 6")
+
+	_, err = parseV2Procfile([]byte("version: 2\ncommands:\n  web:\n    command: /bin/app\n    post: echo $(date\n"), s.Config)
+
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, "5:5: commands.web.post: can't parse command: Unterminated substitution at 6")
+
+	_, err = parseV1Procfile([]byte("# comment\nweb: /bin/app\n\nworker /bin/worker\n"), s.Config)
+
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, "4:1: Procfile v1 should have format: 'some_label: command'")
+
+	app, err := parseV2Procfile([]byte("version: 2\nworking_directory: /srv/app\nlimits:\n  nofile: -1\ncommands:\n  web:\n    command: /bin/app\n    count: -1\n"), s.Config)
+
+	c.Assert(err, IsNil)
+
+	errs := app.Validate()
+
+	c.Assert(errs, HasLen, 2)
+	c.Assert(errs[0].Error(), Equals, "8:5: commands.web.count: must be greater or equal 0")
+	c.Assert(errs[1].Error(), Equals, "4:3: limits.nofile: must be greater or equal 0")
+	c.Assert(errs[1].(*Error).Service, Equals, "web")
+
+	app, err = parseV1Procfile([]byte("web: cd /srv/app && /bin/app\nworker: cd /srv/app && BAD.ENV=1 /bin/worker\n"), s.Config)
+
+	c.Assert(err, IsNil)
+
+	errs = app.Validate()
+
+	c.Assert(errs, HasLen, 1)
+	c.Assert(errs[0].Error(), Equals, "2:1: commands.worker.env.BAD.ENV: Environment variable name BAD.ENV is misformatted and can't be accepted")
+}
+
+func (s *ProcfileSuite) TestConvertV1(c *C) {
+	data, warnings, err := ConvertV1("../testdata/procfile_v1")
+
+	c.Assert(err, IsNil)
+	c.Assert(warnings, HasLen, 0)
+
+	app1, err := Read("../testdata/procfile_v1", s.Config)
+
+	c.Assert(err, IsNil)
+
+	app2, err := parseV2Procfile(data, s.Config)
+
+	c.Assert(err, IsNil)
+	c.Assert(app2.Services, HasLen, len(app1.Services))
+
+	for _, service := range app1.Services {
+		var converted *Service
+
+		for _, svc := range app2.Services {
+			if svc.Name == service.Name {
+				converted = svc
+			}
+		}
+
+		c.Assert(converted, NotNil)
+		c.Assert(converted.Cmd, Equals, service.Cmd)
+		c.Assert(converted.PreCmd, Equals, service.PreCmd)
+		c.Assert(converted.PostCmd, Equals, service.PostCmd)
+		c.Assert(converted.Options.WorkingDir, Equals, service.Options.WorkingDir)
+		c.Assert(converted.Options.LogFile, Equals, service.Options.LogFile)
+		c.Assert(converted.Options.EnvString(), Equals, service.Options.EnvString())
+	}
+
+	_, _, err = ConvertV1("../testdata/procfile_v2")
+
+	c.Assert(err, NotNil)
+
+	c.Assert(extractV1Comments([]byte("# a\n\n# b\nweb: app\nworker: app\n# c\n")), DeepEquals, map[int]string{
+		4: "# a\n# b", -1: "# c",
+	})
+
+	c.Assert(checkV1Command("/bin/app >> app.log 2>&1"), HasLen, 0)
+	c.Assert(checkV1Command("/bin/app >> app.log 2>> error.log"), HasLen, 0)
+	c.Assert(checkV1Command("A=1 echo && /bin/app"), DeepEquals, []string{`environment variables and log redirection in "A=1 echo" are ignored`})
+	c.Assert(checkV1Command("cd /srv && a && b && c && d"), HasLen, 1)
+}
+
+func (s *ProcfileSuite) TestMarshal(c *C) {
+	app, err := Read("../testdata/procfile_v2", s.Config)
+
+	c.Assert(err, IsNil)
+
+	data, err := Marshal(app, 2)
+
+	c.Assert(err, IsNil)
+
+	app2, err := parseV2Procfile(data, s.Config)
+
+	c.Assert(err, IsNil)
+	assertAppsEqual(c, app2, app)
+
+	app, err = Read("../testdata/procfile_v1", s.Config)
+
+	c.Assert(err, IsNil)
+
+	data, err = Marshal(app, 2)
+
+	c.Assert(err, IsNil)
+
+	app2, err = parseV2Procfile(data, s.Config)
+
+	c.Assert(err, IsNil)
+	c.Assert(app2.Services, HasLen, 3)
+
+	_, err = Marshal(app, 1)
+
+	c.Assert(err, NotNil)
+
+	_, err = Marshal(nil, 2)
+
+	c.Assert(err, NotNil)
+}
+
+func (s *ProcfileSuite) TestFormat(c *C) {
+	data, err := os.ReadFile("../testdata/procfile_v2")
+
+	c.Assert(err, IsNil)
+
+	formatted, err := Format(data)
+
+	c.Assert(err, IsNil)
+
+	formattedAgain, err := Format(formatted)
+
+	c.Assert(err, IsNil)
+	c.Assert(string(formattedAgain), Equals, string(formatted))
+
+	app1, err := parseV2Procfile(data, s.Config)
+
+	c.Assert(err, IsNil)
+
+	app2, err := parseV2Procfile(formatted, s.Config)
+
+	c.Assert(err, IsNil)
+	assertAppsEqual(c, app2, app1)
+
+	formatted, err = Format([]byte("commands:\n  web:\n    count: 2 # instances\n    command: /bin/app\nversion: 2\n"))
+
+	c.Assert(err, IsNil)
+	c.Assert(string(formatted), Equals, "version: 2\n\ncommands:\n  web:\n    command: /bin/app\n    count: 2 # instances\n")
+
+	_, err = Format([]byte("web: /bin/app\n"))
+
+	c.Assert(err, NotNil)
+}
+
+func (s *ProcfileSuite) TestVars(c *C) {
+	data := []byte(`version: 2
+vars:
+  ROOT: /srv/${APP_NAME}
+  LOGS: ${ROOT}/log
+working_directory: ${ROOT}/current
+env:
+  JAVA_OPTS: '"${JAVA_OPTS} -Xmx1g"'
+commands:
+  web:
+    command: ${WORKING_DIR}/bin/web --name ${SERVICE_NAME}-${INSTANCE} $${HOME}
+    log: ${LOGS}/web.log
+    count: 2
+  worker:
+    command: /bin/worker ${UNKNOWN}
+    env:
+      PATH: ${HOME}/bin
+`)
+
+	app, err := parseV2Procfile(data, &Config{Name: "myapp", IsStrict: true})
+
+	c.Assert(err, IsNil)
+	c.Assert(app.WorkingDir, Equals, "/srv/myapp/current")
+
+	errs := app.Validate()
+
+	var messages []string
+
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+
+	sort.Strings(messages)
+
+	c.Assert(messages, DeepEquals, []string{
+		`14:5: commands.worker.command: unresolved variable "UNKNOWN"`,
+		`16:7: commands.worker.env.PATH: unresolved variable "HOME"`,
+	})
+
+	for _, service := range app.Services {
+		if service.Name != "web" {
+			continue
+		}
+
+		c.Assert(service.Cmd, Equals, "/srv/myapp/current/bin/web --name web-${INSTANCE} ${HOME}")
+		c.Assert(service.Options.LogFile, Equals, "/srv/myapp/log/web.log")
+		c.Assert(service.Options.Env["JAVA_OPTS"], Equals, `"${JAVA_OPTS} -Xmx1g"`)
+
+		instance := service.WithInstance("2")
+
+		c.Assert(instance.Cmd, Equals, "/srv/myapp/current/bin/web --name web-2 ${HOME}")
+		c.Assert(service.Cmd, Equals, "/srv/myapp/current/bin/web --name web-${INSTANCE} ${HOME}")
+
+		data, err := Marshal(app, 2)
+
+		c.Assert(err, IsNil)
+
+		app2, err := parseV2Procfile(data, &Config{Name: "myapp"})
+
+		c.Assert(err, IsNil)
+		assertAppsEqual(c, app2, app)
+	}
+
+	os.Setenv("INIT_EXPORTER_TEST_VAR", "test")
+
+	app, err = parseV2Procfile([]byte("version: 2\nvars:\n  A: ${B}\n  B: ${A}\ncommands:\n  web:\n    command: /bin/web ${A} ${INIT_EXPORTER_TEST_VAR}\n"), &Config{WorkingDir: "/tmp", UseEnvVars: true})
+
+	c.Assert(err, IsNil)
+
+	errs = app.Validate()
+
+	c.Assert(errs, HasLen, 1)
+	c.Assert(errs[0].Error(), Equals, `7:5: commands.web.command: variable "A" has circular reference`)
+	c.Assert(app.Services[0].Cmd, Equals, "/bin/web ${A} test")
+
+	_, err = parseV2Procfile([]byte("version: 2\nvars:\n  BAD-VAR: 1\ncommands:\n  web:\n    command: /bin/web\n"), s.Config)
+
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, "3:3: vars.BAD-VAR: variable name is misformatted")
+
+	// Variables which are not declared in procfile are expanded by shell
+	app, err = parseV2Procfile([]byte(`version: 2
+working_directory: /srv/app
+commands:
+  web:
+    command: bin/rails s -e ${RAILS_ENV} ${HOME}
+`), s.Config)
+
+	c.Assert(err, IsNil)
+	c.Assert(app.Validate(), HasLen, 0)
+	c.Assert(app.Services[0].Cmd, Equals, "bin/rails s -e ${RAILS_ENV} ${HOME}")
+
+	app, err = parseV2Procfile([]byte(`version: 2
+working_directory: /srv/app
+vars:
+  ROOT: /srv/app
+commands:
+  web:
+    command: bin/rails s -e ${RAILS_ENV}
+    env:
+      RAILS_ENV: production
+  worker:
+    command: bin/worker ${QUEUE}
+    env_file: shared/app.env
+  cron:
+    command: bin/cron ${A} ${B} ${ROOT}
+`), s.Config)
+
+	c.Assert(err, IsNil)
+
+	messages = nil
+
+	for _, err := range app.Validate() {
+		messages = append(messages, err.Error())
+	}
+
+	c.Assert(messages, DeepEquals, []string{
+		`14:5: commands.cron.command: unresolved variable "A"`,
+		`14:5: commands.cron.command: unresolved variable "B"`,
+	})
+}
+
+func (s *ProcfileSuite) TestPorts(c *C) {
+	data := []byte(`version: 2
+working_directory: /srv/app
+commands:
+  web:
+    command: /bin/web --port ${PORT} --name web-${INSTANCE}
+    port: 5000
+    count: 3
+    env:
+      URL: http://127.0.0.1:${PORT}
+`)
+
+	app, err := parseV2Procfile(data, s.Config)
+
+	c.Assert(err, IsNil)
+	c.Assert(app.Validate(), HasLen, 0)
+
+	service := app.Services[0]
+
+	c.Assert(service.Port, Equals, 5000)
+	c.Assert(service.InstancePort(""), Equals, 5000)
+	c.Assert(service.InstancePort("1"), Equals, 5000)
+	c.Assert(service.InstancePort("3"), Equals, 5002)
+
+	instance := service.WithInstance("2")
+
+	c.Assert(instance.Cmd, Equals, "/bin/web --port 5001 --name web-2")
+	c.Assert(instance.Options.Env["URL"], Equals, "http://127.0.0.1:5001")
+	c.Assert(instance.Options.Env["PORT"], Equals, "5001")
+	c.Assert(instance.Options.Env["INSTANCE"], Equals, "2")
+	c.Assert(service.Options.Env["PORT"], Equals, "")
+
+	data, err = Marshal(app, 2)
+
+	c.Assert(err, IsNil)
+
+	app2, err := parseV2Procfile(data, s.Config)
+
+	c.Assert(err, IsNil)
+	assertAppsEqual(c, app2, app)
+
+	_, err = parseV2Procfile([]byte("version: 2\ncommands:\n  web:\n    command: /bin/web\n    port: abc\n"), s.Config)
+
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, "5:5: commands.web.port: expected integer")
+
+	app, err = parseV2Procfile([]byte("version: 2\ncommands:\n  web:\n    command: /bin/web\n    port: 65535\n    count: 2\n"), s.Config)
+
+	c.Assert(err, IsNil)
+
+	errs := app.Validate()
+
+	c.Assert(errs, HasLen, 1)
+	c.Assert(errs[0].Error(), Equals, "5:5: commands.web.port: ports of all instances must be in range 1-65535")
+
+	app, err = parseV2Procfile([]byte("version: 2\ncommands:\n  web:\n    command: /bin/web ${PORT}\n"), s.Config)
+
+	c.Assert(err, IsNil)
+	c.Assert(app.Validate(), HasLen, 0)
+	c.Assert(app.Services[0].Cmd, Equals, "/bin/web ${PORT}")
+
+	app, err = parseV2Procfile([]byte("version: 2\nvars:\n  A: 1\ncommands:\n  web:\n    command: /bin/web ${PORT}\n"), s.Config)
+
+	c.Assert(err, IsNil)
+	c.Assert(app.Validate(), HasLen, 1)
+}
+
+func (s *ProcfileSuite) TestIncludes(c *C) {
+	dir := c.MkDir()
+
+	writeFile(c, dir+"/base.yml", `respawn:
+  count: 5
+  interval: 10
+
+limits:
+  nofile: 4096
+  nproc: 512
+
+env:
+  RAILS_ENV: production
+  LANG: C
+`)
+
+	writeFile(c, dir+"/shared/web.yml", `include: ../base.yml
+
+commands:
+  web:
+    command: /bin/web
+    count: 2
+    limits:
+      nofile: abc
+`)
+
+	writeFile(c, dir+"/Procfile", `version: 2
+
+extends:
+  - base.yml
+
+working_directory: /srv/app
+
+limits:
+  nproc: 1024
+
+env:
+  LANG: en_US.UTF-8
+
+commands:
+  worker:
+    command: /bin/worker
+`)
+
+	app, err := Read(dir+"/Procfile", s.Config)
+
+	c.Assert(err, IsNil)
+	c.Assert(app.Validate(), HasLen, 0)
+	c.Assert(app.Services, HasLen, 1)
+
+	options := app.Services[0].Options
+
+	c.Assert(options.RespawnCount, Equals, 5)
+	c.Assert(options.RespawnInterval, Equals, 10)
+	c.Assert(options.LimitFile, Equals, 4096)
+	c.Assert(options.LimitProc, Equals, 1024)
+	c.Assert(options.Env, DeepEquals, map[string]string{"RAILS_ENV": "production", "LANG": "en_US.UTF-8"})
+
+	pos, _ := app.source.find("limits.nofile")
+	c.Assert(pos, DeepEquals, Position{dir + "/base.yml", 6, 3})
+	pos, _ = app.source.find("limits.nproc")
+	c.Assert(pos, DeepEquals, Position{dir + "/Procfile", 9, 3})
+
+	writeFile(c, dir+"/Procfile", "version: 2\ninclude: shared/web.yml\n")
+
+	_, err = Read(dir+"/Procfile", s.Config)
+
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, dir+"/shared/web.yml:8:7: commands.web.limits.nofile: expected integer")
+
+	writeFile(c, dir+"/base.yml", "include: shared/web.yml\n")
+
+	_, err = Read(dir+"/Procfile", s.Config)
+
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, dir+"/base.yml:1:1: include: Include cycle detected ("+
+		dir+"/Procfile → "+dir+"/shared/web.yml → "+dir+"/base.yml → "+dir+"/shared/web.yml)")
+
+	writeFile(c, dir+"/Procfile", "version: 2\ninclude: unknown.yml\ncommands:\n  web:\n    command: /bin/web\n")
+
+	_, err = Read(dir+"/Procfile", s.Config)
+
+	c.Assert(err, NotNil)
+	c.Assert(err.(*Error).Pos, DeepEquals, Position{dir + "/Procfile", 2, 1})
+	c.Assert(err.(*Error).Rule, Equals, RULE_INVALID_INCLUDE)
+}
+
+func (s *ProcfileSuite) TestProfiles(c *C) {
+	data := []byte(`version: 2
+working_directory: /srv/app
+
+env:
+  RAILS_ENV: production
+
+commands:
+  web:
+    command: /bin/web
+    count: 2
+
+profiles:
+  staging:
+    env:
+      RAILS_ENV: staging
+    commands:
+      web:
+        count: 1
+        resources:
+          memory_max: 512M
+  broken:
+    commands:
+      web:
+        count: abc
+`)
+
+	app, err := parseV2Procfile(data, s.Config)
+
+	c.Assert(err, IsNil)
+	c.Assert(app.Profile, Equals, "")
+	c.Assert(app.Profiles, DeepEquals, []string{"broken", "staging"})
+	c.Assert(app.Services[0].Options.Count, Equals, 2)
+	c.Assert(app.Services[0].Options.Env["RAILS_ENV"], Equals, "production")
+
+	app, err = parseV2Procfile(data, &Config{Name: "test-app", Profile: "staging"})
+
+	c.Assert(err, IsNil)
+	c.Assert(app.Profile, Equals, "staging")
+	c.Assert(app.Validate(), HasLen, 0)
+	c.Assert(app.Services[0].Cmd, Equals, "/bin/web")
+	c.Assert(app.Services[0].Options.Count, Equals, 1)
+	c.Assert(app.Services[0].Options.Env["RAILS_ENV"], Equals, "staging")
+	c.Assert(app.Services[0].Options.Resources.MemoryMax, Equals, "512M")
+
+	_, err = parseV2Procfile(data, &Config{Name: "test-app", Profile: "broken"})
+
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, "24:9: commands.web.count: expected integer")
+
+	_, err = parseV2Procfile(data, &Config{Name: "test-app", Profile: "unknown"})
+
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, `12:1: profiles: Profile "unknown" is not defined`)
+
+	data, err = Format(data)
+
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Matches, `(?s).*commands:.*profiles:.*`)
+}
+
+func (s *ProcfileSuite) TestCommandArgs(c *C) {
+	data := []byte(`version: 2
+working_directory: /srv/app
+vars:
+  JAR: my app.jar
+commands:
+  web:
+    command: [/usr/bin/java, -jar, "${JAR}", "--port=${PORT}", "it's"]
+    pre: [/bin/mkdir, -p, tmp]
+    post: /bin/echo done >> post.log
+    port: 8080
+`)
+
+	app, err := parseV2Procfile(data, s.Config)
+
+	c.Assert(err, IsNil)
+	c.Assert(app.Validate(), HasLen, 0)
+
+	service := app.Services[0]
+
+	c.Assert(service.IsDirectExec(), Equals, false)
+	c.Assert(service.CmdArgs, DeepEquals, []string{"/usr/bin/java", "-jar", "my app.jar", "--port=${PORT}", "it's"})
+	c.Assert(service.Cmd, Equals, `/usr/bin/java -jar 'my app.jar' '--port=${PORT}' 'it'\''s'`)
+	c.Assert(service.PreCmdArgs, DeepEquals, []string{"/bin/mkdir", "-p", "tmp"})
+	c.Assert(service.PostCmdArgs, IsNil)
+	c.Assert(service.PostCmd, Equals, "/bin/echo done >> post.log")
+
+	instance := service.WithInstance("")
+	instance.PostCmd = ""
+
+	c.Assert(instance.IsDirectExec(), Equals, true)
+
+	c.Assert(instance.CmdArgs[3], Equals, "--port=8080")
+	c.Assert(instance.Cmd, Equals, `/usr/bin/java -jar 'my app.jar' --port=8080 'it'\''s'`)
+	c.Assert(service.CmdArgs[3], Equals, "--port=${PORT}")
+
+	data, err = Marshal(app, 2)
+
+	c.Assert(err, IsNil)
+
+	app2, err := parseV2Procfile(data, s.Config)
+
+	c.Assert(err, IsNil)
+	assertAppsEqual(c, app2, app)
+
+	_, err = parseV2Procfile([]byte("version: 2\ncommands:\n  web:\n    command: []\n"), s.Config)
+
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, "4:5: commands.web.command: list of arguments can't be empty")
+
+	_, err = parseV2Procfile([]byte("version: 2\ncommands:\n  web:\n    command: [/bin/app, [a]]\n"), s.Config)
+
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, "4:5: commands.web.command: arguments must be strings")
+}
+
+func (s *ProcfileSuite) TestEnvFiles(c *C) {
+	env, err := ParseEnv([]byte(`# comment
+export A=1
+B="two words"
+C='single # quoted $(id)'
+D="double \"quoted\"\nvalue \$HOME"
+E="multi
+line"
+F=
+G=a#b
+`))
+
+	c.Assert(err, IsNil)
+	c.Assert(env, DeepEquals, map[string]string{
+		"A": "1", "B": "two words", "C": "single # quoted $(id)",
+		"D": "double \"quoted\"\\nvalue $HOME", "E": "multi\nline", "F": "", "G": "a#b",
+	})
+
+	// Syntax which is read differently by bash and systemd
+	_, err = ParseEnv([]byte("A=two words\n"))
+	c.Assert(err, ErrorMatches, "1: Value of environment variable A contains spaces and must be quoted")
+
+	_, err = ParseEnv([]byte("A = 1\n"))
+	c.Assert(err, ErrorMatches, "1: Environment variable A can't have spaces around =")
+
+	_, err = ParseEnv([]byte("A=1 # comment\n"))
+	c.Assert(err, ErrorMatches, "1: Value of environment variable A contains spaces and must be quoted")
+
+	_, err = ParseEnv([]byte("A=\"1\" # comment\n"))
+	c.Assert(err, ErrorMatches, "1: Unexpected data after value of environment variable A")
+
+	_, err = ParseEnv([]byte("A=$(id)\n"))
+	c.Assert(err, ErrorMatches, "1: Value of environment variable A contains shell special characters and must be quoted with single quotes")
+
+	_, err = ParseEnv([]byte("A=\"`id`\"\n"))
+	c.Assert(err, ErrorMatches, "1: Value of environment variable A contains unescaped '`' \\(use single quotes for literal value\\)")
+
+	_, err = ParseEnv([]byte("A=\"$(id)\"\n"))
+	c.Assert(err, ErrorMatches, "1: Value of environment variable A contains unescaped '\\$' .*")
+
+	_, err = ParseEnv([]byte("A=1\n\nA=2\n"))
+	c.Assert(err, ErrorMatches, "3: Environment variable A is already defined on line 1")
+
+	_, err = ParseEnv([]byte("A=1\nBAD.NAME=1\n"))
+	c.Assert(err, ErrorMatches, "2: Environment variable name BAD.NAME is misformatted and can't be accepted")
+
+	_, err = ParseEnv([]byte("A=1\nB\n"))
+	c.Assert(err, ErrorMatches, "2: Line must have NAME=value format")
+
+	_, err = ParseEnv([]byte("A=1\nB=\"abc\nC=1\n"))
+	c.Assert(err, ErrorMatches, "2: Unterminated quoted value")
+
+	_, err = ParseEnv([]byte("A='abc' def\n"))
+	c.Assert(err, ErrorMatches, "1: Unexpected data after value of environment variable A")
+
+	dir := c.MkDir()
+
+	writeFile(c, dir+"/app.env", "A=1\n")
+	writeFile(c, dir+"/broken.env", "A=1\nA=2\n")
+
+	data := []byte(`version: 2
+working_directory: ` + dir + `
+commands:
+  web:
+    command: /bin/app
+    env_file:
+      - app.env
+      - path: local.env
+        required: false
+  worker:
+    command: /bin/worker
+    env_file: [broken.env, missing.env]
+`)
+
+	app, err := parseV2Procfile(data, s.Config)
+
+	c.Assert(err, IsNil)
+	c.Assert(app.Validate(), HasLen, 0)
+
+	sort.Slice(app.Services, func(i, j int) bool { return app.Services[i].Name < app.Services[j].Name })
+
+	c.Assert(app.Services[0].Options.EnvFiles, DeepEquals, []EnvFile{
+		{Path: "app.env", Required: true}, {Path: "local.env", Required: false},
+	})
+
+	errs := app.ValidateEnvFiles()
+
+	c.Assert(errs, HasLen, 2)
+	c.Assert(errs[0], ErrorMatches, dir+"/broken.env:2: Environment variable A is already defined on line 1")
+	c.Assert(errs[1], ErrorMatches, "12:5: commands.worker.env_file: File "+dir+"/missing.env doesn't exist")
+
+	data, err = Marshal(app, 2)
+
+	c.Assert(err, IsNil)
+
+	app2, err := parseV2Procfile(data, s.Config)
+
+	c.Assert(err, IsNil)
+	assertAppsEqual(c, app2, app)
+
+	_, err = parseV2Procfile([]byte("version: 2\ncommands:\n  web:\n    command: /bin/app\n    env_file: [{required: false}]\n"), s.Config)
+
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, "5:5: commands.web.env_file: path to file is not defined")
+}
+
+func (s *ProcfileSuite) TestDependsOn(c *C) {
+	data := []byte(`version: 2
+working_directory: /srv/app
+commands:
+  redis:
+    command: /usr/bin/redis-server
+  migrator:
+    command: /bin/migrate
+    depends_on: redis
+  worker:
+    command: /bin/worker
+    depends_on: [migrator, redis]
+`)
+
+	app, err := parseV2Procfile(data, s.Config)
+
+	c.Assert(err, IsNil)
+	c.Assert(app.Validate(), HasLen, 0)
+	c.Assert(app.GetService("worker").DependsOn, DeepEquals, []string{"migrator", "redis"})
+	c.Assert(app.GetService("migrator").DependsOn, DeepEquals, []string{"redis"})
+	c.Assert(app.GetService("unknown"), IsNil)
+
+	data, err = Marshal(app, 2)
+
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Matches, "(?s).*depends_on: \\[migrator, redis\\].*")
+
+	app2, err := parseV2Procfile(data, s.Config)
+
+	c.Assert(err, IsNil)
+	assertAppsEqual(c, app2, app)
+
+	app, err = parseV2Procfile([]byte(`version: 2
+working_directory: /srv/app
+commands:
+  a:
+    command: /bin/a
+    depends_on: [b]
+  b:
+    command: /bin/b
+    depends_on: [c, unknown]
+  c:
+    command: /bin/c
+    depends_on: [a, c]
+`), s.Config)
+
+	c.Assert(err, IsNil)
+
+	var errs []string
+
+	for _, err := range app.Validate() {
+		errs = append(errs, err.Error())
+	}
+
+	sort.Strings(errs)
+
+	c.Assert(errs, DeepEquals, []string{
+		"12:5: commands.c.depends_on: Dependency cycle detected (a → b → c → a)",
+		"12:5: commands.c.depends_on: service can't depend on itself",
+		"9:5: commands.b.depends_on: unknown service unknown",
+	})
+
+	_, err = parseV2Procfile([]byte("version: 2\ncommands:\n  web:\n    command: /bin/app\n    depends_on: [[a]]\n"), s.Config)
+
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, "5:5: commands.web.depends_on: service names must be strings")
+}
+
+func (s *ProcfileSuite) TestServiceOrder(c *C) {
+	data := []byte(`version: 2
+working_directory: /srv/app
+commands:
+  web:
+    command: /bin/web
+  worker:
+    command: /bin/worker
+  redis:
+    command: /usr/bin/redis-server
+    priority: -10
+  cron:
+    command: /bin/cron
+  migrator:
+    command: /bin/migrate
+    priority: -5
+`)
+
+	for i := 0; i < 10; i++ {
+		app, err := parseV2Procfile(data, s.Config)
+
+		c.Assert(err, IsNil)
+		c.Assert(app.Validate(), HasLen, 0)
+
+		var names []string
+
+		for _, service := range app.Services {
+			names = append(names, service.Name)
+		}
+
+		c.Assert(names, DeepEquals, []string{"redis", "migrator", "web", "worker", "cron"})
+		c.Assert(app.Services[0].Priority, Equals, -10)
+	}
+
+	_, err := parseV2Procfile([]byte("version: 2\ncommands:\n  web:\n    command: /bin/app\n    priority: high\n"), s.Config)
+
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, "5:5: commands.web.priority: expected integer")
+}
+
+func (s *ProcfileSuite) TestUserAndGroup(c *C) {
+	data := []byte(`version: 2
+working_directory: /srv/app
+user: app
+group: app
+commands:
+  web:
+    command: /bin/web
+  sidekiq:
+    command: /bin/sidekiq
+    user: sidekiq
+`)
+
+	app, err := parseV2Procfile(data, s.Config)
+
+	c.Assert(err, IsNil)
+	c.Assert(app.Validate(), HasLen, 0)
+	c.Assert(app.User, Equals, "app")
+	c.Assert(app.GetService("web").GetUser(), Equals, "app")
+	c.Assert(app.GetService("sidekiq").GetUser(), Equals, "sidekiq")
+	c.Assert(app.GetService("sidekiq").GetGroup(), Equals, "app")
+
+	data, err = Marshal(app, 2)
+
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Matches, "(?s).*\nuser: app\ngroup: app\n.*    user: sidekiq\n.*")
+
+	app2, err := parseV2Procfile(data, s.Config)
+
+	c.Assert(err, IsNil)
+	assertAppsEqual(c, app2, app)
+
+	app, err = parseV2Procfile([]byte("version: 2\nworking_directory: /srv/app\ncommands:\n  web:\n    command: /bin/web\n"), s.Config)
+
+	c.Assert(err, IsNil)
+	c.Assert(app.GetService("web").GetUser(), Equals, s.Config.User)
+	c.Assert(app.GetService("web").GetGroup(), Equals, s.Config.Group)
+
+	app, err = parseV2Procfile([]byte(`version: 2
+working_directory: /srv/app
+user: "root\nExecStartPre=/bin/false"
+commands:
+  web:
+    command: /bin/web
+    user: root
+    group: unknown-group-init-exporter
+`), s.Config)
+
+	c.Assert(err, IsNil)
+
+	errs := app.Validate()
+
+	c.Assert(errs, HasLen, 1)
+	c.Assert(errs[0].Error(), Equals, "3:1: user: User name root\nExecStartPre=/bin/false is misformatted and can't be accepted")
+
+	app.User = "root"
+	errs = app.ValidateUsers()
+
+	c.Assert(errs, HasLen, 1)
+	c.Assert(errs[0].Error(), Equals, "8:5: commands.web.group: Group unknown-group-init-exporter doesn't exist")
+}
+
+func (s *ProcfileSuite) TestSchedules(c *C) {
+	data := []byte(`version: 2
+working_directory: /srv/app
+vars:
+  ROOT: /srv/app
+env:
+  RAILS_ENV: production
+commands:
+  web:
+    command: /bin/web
+schedules:
+  sync:
+    command: ${ROOT}/bin/sync --job ${SERVICE_NAME}
+    interval: 15m
+  cleanup:
+    command: [bin/cleanup, --all]
+    calendar: Mon..Fri *-*-* 03:30:00
+    working_directory: /srv/cleanup
+    user: cleaner
+    env:
+      MODE: full
+`)
+
+	app, err := parseV2Procfile(data, s.Config)
+
+	c.Assert(err, IsNil)
+	c.Assert(app.Validate(), HasLen, 0)
+	c.Assert(app.Schedules, HasLen, 2)
+
+	sync, cleanup := app.Schedules[0], app.Schedules[1]
+
+	c.Assert(sync.Name, Equals, "sync")
+	c.Assert(sync.Cmd, Equals, "/srv/app/bin/sync --job sync")
+	c.Assert(sync.GetIntervalSeconds(), Equals, 900)
+	c.Assert(sync.Options.WorkingDir, Equals, "/srv/app")
+	c.Assert(sync.Options.Env, DeepEquals, map[string]string{"RAILS_ENV": "production"})
+	c.Assert(sync.GetUser(), Equals, s.Config.User)
+	c.Assert(cleanup.CmdArgs, DeepEquals, []string{"bin/cleanup", "--all"})
+	c.Assert(cleanup.Calendar, Equals, "Mon..Fri *-*-* 03:30:00")
+	c.Assert(cleanup.Options.WorkingDir, Equals, "/srv/cleanup")
+	c.Assert(cleanup.Options.Env, DeepEquals, map[string]string{"RAILS_ENV": "production", "MODE": "full"})
+	c.Assert(cleanup.GetUser(), Equals, "cleaner")
+	c.Assert(cleanup.AsService().Application, Equals, app)
+
+	data, err = Marshal(app, 2)
+
+	c.Assert(err, IsNil)
+
+	app2, err := parseV2Procfile(data, s.Config)
+
+	c.Assert(err, IsNil)
+	assertAppsEqual(c, app2, app)
+
+	app, err = parseV2Procfile([]byte(`version: 2
+working_directory: /srv/app
+commands:
+  web:
+    command: /bin/web
+schedules:
+  web:
+    command: /bin/job
+    interval: 1m
+  a:
+    command: /bin/a
+    calendar: daily
+    interval: 5m
+  b:
+    calendar: "daily; rm -rf /"
+  c:
+    command: /bin/c
+    interval: 5 minutes
+    env:
+      A: "a b"
+`), s.Config)
+
+	c.Assert(err, IsNil)
+
+	var errs []string
+
+	for _, err := range app.Validate() {
+		errs = append(errs, err.Error())
+	}
+
+	sort.Strings(errs)
+
+	c.Assert(errs, DeepEquals, []string{
+		"10:3: schedules.a: job must contain only one of calendar or interval",
+		"14:3: schedules.b: job command can't be empty",
+		"15:5: schedules.b.calendar: Calendar spec \"daily; rm -rf /\" is misformatted and can't be accepted",
+		"18:5: schedules.c.interval: must contain number with optional unit (s, m, h or d)",
+		"20:7: schedules.c.env.A: Environment variable A has unquoted value with spaces",
+		"7:3: schedules.web: Job name web is already used by command",
+	})
+
+	app, err = parseV2Procfile([]byte("version: 2\nworking_directory: /srv/app\ncommands:\n  web:\n    command: /bin/app\nschedules:\n  job:\n    command: /bin/job\n    interval: 1h\n    kill_timeout: 10\n"), &Config{IsStrict: true})
+
+	c.Assert(err, IsNil)
+
+	errs = nil
+
+	for _, err := range app.Validate() {
+		errs = append(errs, err.Error())
+	}
+
+	c.Assert(errs, DeepEquals, []string{
+		"10:5: schedules.job.kill_timeout: unknown property",
+	})
+
+	data, err = Format([]byte("version: 2\nschedules:\n  job:\n    interval: 1h\n    command: /bin/job\ncommands:\n  web:\n    command: /bin/app\n"))
+
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "version: 2\n\ncommands:\n  web:\n    command: /bin/app\n\nschedules:\n  job:\n    command: /bin/job\n    interval: 1h\n")
+}
+
+func (s *ProcfileSuite) TestTasks(c *C) {
+	data := []byte(`version: 2
+working_directory: /srv/app
+user: deploy
+env:
+  RAILS_ENV: production
+commands:
+  web:
+    command: /bin/web
+tasks:
+  migrate:
+    command: bin/rake db:migrate
+  assets:
+    command: [bin/rake, assets:precompile]
+    working_directory: /srv/assets
+    env:
+      NODE_ENV: production
+`)
+
+	app, err := parseV2Procfile(data, s.Config)
+
+	c.Assert(err, IsNil)
+	c.Assert(app.Validate(), HasLen, 0)
+	c.Assert(app.HasTasks(), Equals, true)
+	c.Assert(app.Tasks, HasLen, 2)
+
+	migrate, assets := app.Tasks[0], app.Tasks[1]
+
+	c.Assert(migrate.Name, Equals, "migrate")
+	c.Assert(migrate.Cmd, Equals, "bin/rake db:migrate")
+	c.Assert(migrate.Options.WorkingDir, Equals, "/srv/app")
+	c.Assert(migrate.GetUser(), Equals, "deploy")
+	c.Assert(assets.CmdArgs, DeepEquals, []string{"bin/rake", "assets:precompile"})
+	c.Assert(assets.Options.WorkingDir, Equals, "/srv/assets")
+	c.Assert(assets.Options.Env, DeepEquals, map[string]string{"RAILS_ENV": "production", "NODE_ENV": "production"})
+	c.Assert(assets.AsService().Application, Equals, app)
+
+	data, err = Marshal(app, 2)
+
+	c.Assert(err, IsNil)
+
+	app2, err := parseV2Procfile(data, s.Config)
+
+	c.Assert(err, IsNil)
+	assertAppsEqual(c, app2, app)
+
+	app, err = parseV2Procfile([]byte(`version: 2
+working_directory: /srv/app
+commands:
+  web:
+    command: /bin/web
+schedules:
+  sync:
+    command: /bin/sync
+    interval: 1h
+tasks:
+  web:
+    command: /bin/task
+  sync:
+    command: /bin/sync
+  empty:
+    working_directory: /srv/empty
+`), s.Config)
+
+	c.Assert(err, IsNil)
+
+	var errs []string
+
+	for _, err := range app.Validate() {
+		errs = append(errs, err.Error())
+	}
+
+	sort.Strings(errs)
+
+	c.Assert(errs, DeepEquals, []string{
+		"11:3: tasks.web: Task name web is already used by command",
+		"13:3: tasks.sync: Task name sync is already used by scheduled job",
+		"15:3: tasks.empty: task command can't be empty",
+	})
+
+	app, err = parseV2Procfile([]byte("version: 2\nworking_directory: /srv/app\ncommands:\n  web:\n    command: /bin/web\n    count: 2\nschedules:\n  web1:\n    command: /bin/sync\n    interval: 1h\ntasks:\n  web2:\n    command: /bin/task\n  web3:\n    command: /bin/task\n"), s.Config)
+
+	c.Assert(err, IsNil)
+
+	errs = nil
+
+	for _, err := range app.Validate() {
+		errs = append(errs, err.Error())
+	}
+
+	c.Assert(errs, DeepEquals, []string{
+		"8:3: schedules.web1: Job name web1 is already used by command",
+		"12:3: tasks.web2: Task name web2 is already used by command",
+	})
+
+	_, err = parseV2Procfile([]byte("version: 2\ncommands:\n  web:\n    command: /bin/app\ntasks:\n  migrate: bin/migrate\n"), s.Config)
+
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, "6:3: tasks.migrate: expected map")
+
+	app, err = parseV2Procfile([]byte("version: 2\nworking_directory: /srv/app\ncommands:\n  web:\n    command: /bin/app\ntasks:\n  migrate:\n    command: bin/migrate\n    interval: 1h\n"), &Config{IsStrict: true})
+
+	c.Assert(err, IsNil)
+
+	errs = nil
+
+	for _, err := range app.Validate() {
+		errs = append(errs, err.Error())
+	}
+
+	c.Assert(errs, DeepEquals, []string{
+		"9:5: tasks.migrate.interval: unknown property",
+	})
+
+	data, err = Format([]byte("version: 2\ntasks:\n  migrate:\n    env:\n      A: 1\n    command: bin/migrate\ncommands:\n  web:\n    command: /bin/app\n"))
+
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "version: 2\n\ncommands:\n  web:\n    command: /bin/app\n\ntasks:\n  migrate:\n    command: bin/migrate\n    env:\n      A: 1\n")
+}
+
+func (s *ProcfileSuite) TestServiceType(c *C) {
+	data := []byte(`version: 2
+working_directory: /srv/app
+type: exec
+commands:
+  web:
+    command: [/bin/web, --notify]
+    type: notify
+    watchdog_sec: 30
+  legacy:
+    command: /usr/sbin/legacyd
+    type: forking
+    pid_file: /run/legacyd/${SERVICE_NAME}.pid
+  worker:
+    command: /bin/worker
+    notify_access: main
+`)
+
+	app, err := parseV2Procfile(data, s.Config)
+
+	c.Assert(err, IsNil)
+	c.Assert(app.Validate(), HasLen, 0)
+
+	web, legacy, worker := app.GetService("web"), app.GetService("legacy"), app.GetService("worker")
+
+	c.Assert(web.Options.GetType(), Equals, "notify")
+	c.Assert(web.Options.IsNotifyType(), Equals, true)
+	c.Assert(web.Options.WatchdogSec, Equals, 30)
+	c.Assert(legacy.Options.PIDFile, Equals, "/run/legacyd/legacy.pid")
+	c.Assert(worker.Options.GetType(), Equals, "exec")
+	c.Assert(worker.Options.NotifyAccess, Equals, "main")
+	c.Assert((&ServiceOptions{}).GetType(), Equals, "simple")
+
+	data, err = Marshal(app, 2)
+
+	c.Assert(err, IsNil)
+
+	app2, err := parseV2Procfile(data, s.Config)
+
+	c.Assert(err, IsNil)
+	assertAppsEqual(c, app2, app)
+
+	app, err = parseV2Procfile([]byte(`version: 2
+working_directory: /srv/app
+commands:
+  a:
+    command: /bin/a
+    type: oneshot
+    notify_access: everyone
+  b:
+    command: /bin/b
+    watchdog_sec: 10
+    pid_file: run/b.pid
+`), s.Config)
+
+	c.Assert(err, IsNil)
+
+	var errs []string
+
+	for _, err := range app.Validate() {
+		errs = append(errs, err.Error())
+	}
+
+	sort.Strings(errs)
+
+	c.Assert(errs, DeepEquals, []string{
+		"10:5: commands.b.watchdog_sec: watchdog can be used only for service with 'notify' type",
+		"11:5: commands.b.pid_file: PID file can be used only for service with 'forking' type",
+		"11:5: commands.b.pid_file: Path run/b.pid is not safe and can't be accepted",
+		"6:5: commands.a.type: must contain 'simple', 'exec', 'notify' or 'forking'",
+		"7:5: commands.a.notify_access: must contain 'none', 'main', 'exec' or 'all'",
+	})
+}
+
+func (s *ProcfileSuite) TestSecurity(c *C) {
+	data := []byte(`version: 2
+working_directory: /srv/app
+security:
+  preset: default
+commands:
+  web:
+    command: /bin/web
+    security:
+      private_devices: false
+      read_write_paths: /srv/app/uploads
+      capability_bounding_set: []
+      ambient_capabilities: CAP_NET_BIND_SERVICE
+  worker:
+    command: /bin/worker
+    security:
+      preset: strict
+      protect_home: read-only
+      system_call_filter: [~@mount, ~@reboot]
+`)
+
+	app, err := parseV2Procfile(data, s.Config)
+
+	c.Assert(err, IsNil)
+	c.Assert(app.Validate(), HasLen, 0)
+
+	web, worker := app.GetService("web"), app.GetService("worker")
+
+	c.Assert(web.Options.IsSecuritySet(), Equals, true)
+
+	security := web.Options.Security.Resolve()
+
+	c.Assert(security.NoNewPrivileges, Equals, "yes")
+	c.Assert(security.ProtectSystem, Equals, "full")
+	c.Assert(security.PrivateDevices, Equals, "no")
+	c.Assert(security.ReadWritePaths, DeepEquals, []string{"/srv/app/uploads"})
+	c.Assert(security.CapabilityBoundingSet, NotNil)
+	c.Assert(security.CapabilityBoundingSet, HasLen, 0)
+	c.Assert(security.AmbientCapabilities, DeepEquals, []string{"CAP_NET_BIND_SERVICE"})
+
+	security = worker.Options.Security.Resolve()
+
+	c.Assert(security.ProtectSystem, Equals, "strict")
+	c.Assert(security.ProtectHome, Equals, "read-only")
+	c.Assert(security.SystemCallFilter, DeepEquals, []string{"~@mount", "~@reboot"})
+	c.Assert(security.RestrictAddressFamilies, DeepEquals, []string{"AF_UNIX", "AF_INET", "AF_INET6"})
+
+	data, err = Marshal(app, 2)
+
+	c.Assert(err, IsNil)
+
+	app2, err := parseV2Procfile(data, s.Config)
+
+	c.Assert(err, IsNil)
+	assertAppsEqual(c, app2, app)
+	c.Assert(app2.GetService("web").Options.Security, DeepEquals, web.Options.Security)
+
+	config := *s.Config
+	config.SecurityPreset = SECURITY_PRESET_STRICT
+
+	app, err = parseV2Procfile([]byte(`version: 2
+working_directory: /srv/app
+commands:
+  web:
+    command: /bin/web
+  worker:
+    command: /bin/worker
+    security:
+      protect_system: full
+  legacy:
+    command: /bin/legacy
+    security:
+      preset: none
+`), &config)
+
+	c.Assert(err, IsNil)
+	c.Assert(app.GetService("web").Options.Security.Preset, Equals, SECURITY_PRESET_STRICT)
+	c.Assert(app.GetService("worker").Options.Security.Resolve().ProtectSystem, Equals, "full")
+	c.Assert(app.GetService("worker").Options.Security.Resolve().ProtectHome, Equals, "yes")
+	c.Assert(app.GetService("legacy").Options.IsSecuritySet(), Equals, false)
+
+	app, err = parseV2Procfile([]byte(`version: 2
+working_directory: /srv/app
+commands:
+  a:
+    command: /bin/a
+    security:
+      preset: paranoid
+      protect_system: always
+      private_tmp: maybe
+      read_only_paths: [etc/app]
+      capability_bounding_set: [NET_ADMIN]
+`), s.Config)
+
+	c.Assert(err, IsNil)
+
+	var errs []string
+
+	for _, err := range app.Validate() {
+		errs = append(errs, err.Error())
+	}
+
+	sort.Strings(errs)
+
+	c.Assert(errs, DeepEquals, []string{
+		"10:7: commands.a.security.read_only_paths: Path etc/app is not safe and can't be accepted",
+		"11:7: commands.a.security.capability_bounding_set: Name of capability NET_ADMIN is misformatted and can't be accepted",
+		"7:7: commands.a.security.preset: must contain 'none', 'default' or 'strict'",
+		"8:7: commands.a.security.protect_system: must contain boolean value, 'full' or 'strict'",
+		"9:7: commands.a.security.private_tmp: must contain boolean value",
+	})
+}
+
+func (s *ProcfileSuite) TestSecrets(c *C) {
+	data := []byte(`version: 2
+working_directory: /srv/app
+secrets:
+  db_password: /etc/app/db_password
+  api_key: /etc/app/api_key
+commands:
+  web:
+    command: /bin/web
+    secrets:
+      api_key:
+        file: /etc/app/web_api_key.cred
+        encrypted: true
+  worker:
+    command: /bin/worker
+`)
+
+	app, err := parseV2Procfile(data, s.Config)
+
+	c.Assert(err, IsNil)
+	c.Assert(app.Validate(), HasLen, 0)
+
+	web, worker := app.GetService("web"), app.GetService("worker")
+
+	c.Assert(web.Options.IsSecretsSet(), Equals, true)
+	c.Assert(web.Options.HasEncryptedSecrets(), Equals, true)
+	c.Assert(web.Options.Secrets, DeepEquals, []*Secret{
+		{Name: "api_key", File: "/etc/app/web_api_key.cred", Encrypted: true},
+		{Name: "db_password", File: "/etc/app/db_password"},
+	})
+	c.Assert(worker.Options.HasEncryptedSecrets(), Equals, false)
+	c.Assert(worker.Options.Secrets, DeepEquals, []*Secret{
+		{Name: "api_key", File: "/etc/app/api_key"},
+		{Name: "db_password", File: "/etc/app/db_password"},
+	})
+
+	data, err = Marshal(app, 2)
+
+	c.Assert(err, IsNil)
+
+	app2, err := parseV2Procfile(data, s.Config)
+
+	c.Assert(err, IsNil)
+	assertAppsEqual(c, app2, app)
+	c.Assert(app2.GetService("web").Options.Secrets, DeepEquals, web.Options.Secrets)
+
+	_, err = parseV2Procfile([]byte(`version: 2
+working_directory: /srv/app
+commands:
+  web:
+    command: /bin/web
+    secrets: [/etc/app/db_password]
+`), s.Config)
+
+	c.Assert(err, ErrorMatches, "6:5: commands.web.secrets: expected map")
+
+	app, err = parseV2Procfile([]byte(`version: 2
+working_directory: /srv/app
+commands:
+  web:
+    command: /bin/web
+    secrets:
+      db:password: /etc/app/db_password
+      api_key: etc/app/api_key
+      token:
+        encrypted: true
+`), s.Config)
+
+	c.Assert(err, IsNil)
+
+	var errs []string
+
+	for _, err := range app.Validate() {
+		errs = append(errs, err.Error())
+	}
+
+	sort.Strings(errs)
+
+	c.Assert(errs, DeepEquals, []string{
+		"7:7: commands.web.secrets.db:password: Secret name db:password is misformatted and can't be accepted",
+		"8:7: commands.web.secrets.api_key: path to secret file must be absolute",
+		"9:7: commands.web.secrets.token: path to secret file can't be empty",
+	})
+}
+
+func (s *ProcfileSuite) TestLogrotate(c *C) {
+	config := *s.Config
+	config.Logrotate = &Logrotate{Period: "daily", Rotate: 7, Compress: true}
+
+	data := []byte(`version: 2
+working_directory: /srv/app
+logrotate:
+  rotate: 14
+  max_size: 100M
+  compress: false
+commands:
+  web:
+    command: /bin/web
+`)
+
+	app, err := parseV2Procfile(data, &config)
+
+	c.Assert(err, IsNil)
+	c.Assert(app.Validate(), HasLen, 0)
+	c.Assert(app.IsLogrotateEnabled(), Equals, true)
+	c.Assert(app.Logrotate, DeepEquals, &Logrotate{Period: "daily", Rotate: 14, MaxSize: "100M"})
+	c.Assert(config.Logrotate, DeepEquals, &Logrotate{Period: "daily", Rotate: 7, Compress: true})
+
+	data, err = Marshal(app, 2)
+
+	c.Assert(err, IsNil)
+
+	app2, err := parseV2Procfile(data, &config)
+
+	c.Assert(err, IsNil)
+	c.Assert(app2.Logrotate, DeepEquals, app.Logrotate)
+
+	app, err = parseV2Procfile([]byte("version: 2\nworking_directory: /srv/app\ncommands:\n  web: /bin/web\n"), &config)
+
+	c.Assert(err, IsNil)
+	c.Assert(app.Logrotate, DeepEquals, config.Logrotate)
+
+	app, err = parseV2Procfile([]byte("version: 2\nworking_directory: /srv/app\nlogrotate: false\ncommands:\n  web: /bin/web\n"), &config)
+
+	c.Assert(err, IsNil)
+	c.Assert(app.IsLogrotateEnabled(), Equals, false)
+
+	app, err = parseV2Procfile([]byte("version: 2\nworking_directory: /srv/app\ncommands:\n  web: /bin/web\n"), s.Config)
+
+	c.Assert(err, IsNil)
+	c.Assert(app.IsLogrotateEnabled(), Equals, false)
+
+	app, err = parseV2Procfile([]byte("version: 2\nworking_directory: /srv/app\nlogrotate: true\ncommands:\n  web: /bin/web\n"), s.Config)
+
+	c.Assert(err, IsNil)
+	c.Assert(app.Logrotate, DeepEquals, &Logrotate{Period: "weekly", Rotate: 4, Compress: true})
+
+	app, err = parseV2Procfile([]byte(`version: 2
+working_directory: /srv/app
+logrotate:
+  period: hourly
+  rotate: -1
+  max_size: 10T
+commands:
+  web: /bin/web
+`), s.Config)
+
+	c.Assert(err, IsNil)
+
+	var errs []string
+
+	for _, err := range app.Validate() {
+		errs = append(errs, err.Error())
+	}
+
+	c.Assert(errs, DeepEquals, []string{
+		"4:3: logrotate.period: must contain 'daily', 'weekly', 'monthly' or 'yearly'",
+		"5:3: logrotate.rotate: must be greater or equal 0",
+		"6:3: logrotate.max_size: must contain number with optional unit (k, M or G)",
+	})
+
+	_, err = parseV2Procfile([]byte("version: 2\nworking_directory: /srv/app\nlogrotate: daily\ncommands:\n  web: /bin/web\n"), s.Config)
+
+	c.Assert(err, ErrorMatches, "3:1: logrotate: expected boolean")
+}
+
+func (s *ProcfileSuite) TestHealthCheck(c *C) {
+	data := []byte(`version: 2
+working_directory: /srv/app
+commands:
+  web:
+    command: /bin/web
+    port: 8080
+    count: 2
+    healthcheck:
+      http: http://127.0.0.1:${PORT}/health
+      interval: 2
+      retries: 30
+  redis:
+    command: /usr/bin/redis-server
+    healthcheck:
+      tcp: 6379
+  worker:
+    command: /bin/worker
+    healthcheck:
+      command: test -f /tmp/worker.ready
+      timeout: 1
+`)
+
+	app, err := parseV2Procfile(data, s.Config)
+
+	c.Assert(err, IsNil)
+	c.Assert(app.Validate(), HasLen, 0)
+
+	web := app.GetService("web")
+
+	c.Assert(web.HasHealthCheck(), Equals, true)
+	c.Assert(web.HealthCheck.GetInterval(), Equals, 2)
+	c.Assert(web.HealthCheck.GetTimeout(), Equals, DEFAULT_HEALTH_TIMEOUT)
+	c.Assert(web.HealthCheck.GetRetries(), Equals, 30)
+	c.Assert(web.HealthCheck.GetMaxDuration(), Equals, 150)
+	c.Assert(web.WithInstance("2").HealthCheck.HTTP, Equals, "http://127.0.0.1:8081/health")
+	c.Assert(web.HealthCheck.HTTP, Equals, "http://127.0.0.1:${PORT}/health")
+
+	host, port := app.GetService("redis").HealthCheck.GetTCPAddress()
+
+	c.Assert(host, Equals, "127.0.0.1")
+	c.Assert(port, Equals, "6379")
+	c.Assert(app.GetService("worker").HealthCheck.GetRetries(), Equals, DEFAULT_HEALTH_RETRIES)
+
+	data, err = Marshal(app, 2)
+
+	c.Assert(err, IsNil)
+
+	app2, err := parseV2Procfile(data, s.Config)
+
+	c.Assert(err, IsNil)
+	assertAppsEqual(c, app2, app)
+	c.Assert(app2.GetService("web").HealthCheck, DeepEquals, web.HealthCheck)
+
+	// ${PORT} in tcp check is resolved only on export
+	app, err = parseV2Procfile([]byte("version: 2\nworking_directory: /srv/app\ncommands:\n  web:\n    command: /bin/web\n    port: 8000\n    count: 2\n    healthcheck:\n      tcp: ${PORT}\n"), s.Config)
+
+	c.Assert(err, IsNil)
+	c.Assert(app.Validate(), HasLen, 0)
+	c.Assert(app.Services[0].HealthCheck.TCP, Equals, "${PORT}")
+	c.Assert(app.Services[0].WithInstance("2").HealthCheck.TCP, Equals, "8001")
+
+	app, err = parseV2Procfile([]byte("version: 2\nworking_directory: /srv/app\ncommands:\n  web:\n    command: /bin/web\n    healthcheck:\n      tcp: ${PORT}\n"), s.Config)
+
+	c.Assert(err, IsNil)
+	c.Assert(app.Validate(), HasLen, 1)
+
+	app, err = parseV2Procfile([]byte(`version: 2
+working_directory: /srv/app
+commands:
+  a:
+    command: /bin/a
+    healthcheck:
+      tcp: 8080
+      http: http://127.0.0.1:8080
+  b:
+    command: /bin/b
+    healthcheck:
+      tcp: localhost:80000
+      retries: -1
+  c:
+    command: /bin/c
+    healthcheck:
+      http: ftp://127.0.0.1
+`), s.Config)
+
+	c.Assert(err, IsNil)
+
+	var errs []string
+
+	for _, err := range app.Validate() {
+		errs = append(errs, err.Error())
+	}
+
+	sort.Strings(errs)
+
+	c.Assert(errs, DeepEquals, []string{
+		"12:7: commands.b.healthcheck.tcp: must contain port or host:port with port in range 1-65535",
+		"13:7: commands.b.healthcheck.retries: must be greater or equal 0",
+		"17:7: commands.c.healthcheck.http: must contain HTTP or HTTPS URL",
+		"6:5: commands.a.healthcheck: health check must contain only one of command, tcp or http",
+	})
+
+	_, err = parseV2Procfile([]byte("version: 2\ncommands:\n  web:\n    command: /bin/app\n    healthcheck: 8080\n"), s.Config)
+
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, "5:5: commands.web.healthcheck: expected map")
+
+	app, err = parseV2Procfile([]byte("version: 2\nworking_directory: /srv/app\ncommands:\n  web:\n    command: /bin/app\n    healthcheck:\n      tcp: 8080\n      intreval: 1\n"), &Config{IsStrict: true})
+
+	c.Assert(err, IsNil)
+
+	errs = nil
+
+	for _, err := range app.Validate() {
+		errs = append(errs, err.Error())
+	}
+
+	c.Assert(errs, DeepEquals, []string{
+		`8:7: commands.web.healthcheck.intreval: unknown property (did you mean "interval"?)`,
+	})
+}
+
+func (s *ProcfileSuite) TestSockets(c *C) {
+	data := []byte(`version: 2
+working_directory: /srv/app
+commands:
+  web:
+    command: [/bin/web, --listen-fd, "3"]
+    sockets:
+      stream: [8080, "127.0.0.1:8443"]
+      backlog: 128
+  dns:
+    command: /bin/dns
+    sockets:
+      datagram: 5353
+  echo:
+    command: /bin/echo-server
+    sockets:
+      stream: /run/echo.sock
+      accept: true
+  worker:
+    command: /bin/worker
+`)
+
+	app, err := parseV2Procfile(data, s.Config)
+
+	c.Assert(err, IsNil)
+	c.Assert(app.Validate(), HasLen, 0)
+
+	web := app.GetService("web")
+
+	c.Assert(web.HasSockets(), Equals, true)
+	c.Assert(web.Sockets, DeepEquals, &Sockets{Stream: []string{"8080", "127.0.0.1:8443"}, Backlog: 128})
+	c.Assert(app.GetService("dns").Sockets.Datagram, DeepEquals, []string{"5353"})
+	c.Assert(app.GetService("echo").Sockets.Accept, Equals, true)
+	c.Assert(app.GetService("worker").HasSockets(), Equals, false)
+
+	data, err = Marshal(app, 2)
+
+	c.Assert(err, IsNil)
+
+	app2, err := parseV2Procfile(data, s.Config)
+
+	c.Assert(err, IsNil)
+	assertAppsEqual(c, app2, app)
+
+	app, err = parseV2Procfile([]byte(`version: 2
+working_directory: /srv/app
+commands:
+  a:
+    command: /bin/a
+    sockets:
+      backlog: 10
+  b:
+    command: /bin/b
+    sockets:
+      stream: [70000, "host:port", "/run/b.sock; rm"]
+      backlog: -1
+  c:
+    command: /bin/c
+    count: 2
+    sockets:
+      stream: 9000
+  d:
+    command: /bin/d
+    healthcheck:
+      tcp: 9001
+    sockets:
+      stream: 9001
+      accept: true
+`), s.Config)
+
+	c.Assert(err, IsNil)
+
+	var errs []string
+
+	for _, err := range app.Validate() {
+		errs = append(errs, err.Error())
+	}
+
+	sort.Strings(errs)
+
+	c.Assert(errs, DeepEquals, []string{
+		"11:7: commands.b.sockets.stream: Socket address /run/b.sock; rm is misformatted and can't be accepted",
+		"11:7: commands.b.sockets.stream: Socket address 70000 must contain port in range 1-65535",
+		"11:7: commands.b.sockets.stream: Socket address host:port must contain port in range 1-65535",
+		"12:7: commands.b.sockets.backlog: must be greater or equal 0",
+		"16:5: commands.c.sockets: sockets can't be used for command with multiple instances",
+		"24:7: commands.d.sockets.accept: health check can't be used for command started for every connection",
+		"6:5: commands.a.sockets: sockets must contain at least one stream or datagram address",
+	})
+
+	_, err = parseV2Procfile([]byte("version: 2\ncommands:\n  web:\n    command: /bin/app\n    sockets: 8080\n"), s.Config)
+
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, "5:5: commands.web.sockets: expected map")
+
+	app, err = parseV2Procfile([]byte("version: 2\nworking_directory: /srv/app\ncommands:\n  web:\n    command: /bin/app\n    sockets:\n      stream: 8080\n      acept: true\n"), &Config{IsStrict: true})
+
+	c.Assert(err, IsNil)
+
+	errs = nil
+
+	for _, err := range app.Validate() {
+		errs = append(errs, err.Error())
+	}
+
+	c.Assert(errs, DeepEquals, []string{
+		`8:7: commands.web.sockets.acept: unknown property (did you mean "accept"?)`,
+	})
+}
+
+func (s *ProcfileSuite) TestLint(c *C) {
+	app, err := Read("../testdata/procfile_v2_lint", s.Config)
+
+	c.Assert(err, IsNil)
+	c.Assert(app.Validate(), HasLen, 0)
+
+	var result []string
+
+	for _, w := range app.Lint(&LintConfig{Format: "upstart"}) {
+		c.Assert(w.Severity, Equals, SEVERITY_WARNING)
+		result = append(result, w.Rule+" "+w.Error())
+	}
+
+	sort.Strings(result)
+
+	c.Assert(result, DeepEquals, []string{
+		"count-above-cpus ../testdata/procfile_v2_lint:9:5: commands.my_tail_cmd.count: count (4) is greater than number of CPUs in cpu_affinity (2)",
+		"low-nofile ../testdata/procfile_v2_lint:11:7: commands.my_tail_cmd.limits.nofile: descriptors limit 512 is less than 1024",
+		"memory-high-above-max ../testdata/procfile_v2_lint:14:7: commands.my_tail_cmd.resources.memory_high: memory_high (2G) is greater than memory_max (1G)",
+		"no-kill-timeout ../testdata/procfile_v2_lint:8:5: commands.my_tail_cmd.respawn: respawn is disabled and kill_timeout is not set, service can hang on stop",
+		"upstart-resources ../testdata/procfile_v2_lint:12:5: commands.my_tail_cmd.resources: resources limits are not supported by upstart and will be ignored",
+	})
+
+	warnings := app.Lint(&LintConfig{
+		Format: "systemd",
+		Severity: map[string]string{
+			LINT_NO_KILL_TIMEOUT: SEVERITY_OFF,
+			LINT_LOW_NOFILE:      SEVERITY_ERROR,
+		},
+	})
+
+	c.Assert(warnings, HasLen, 3)
+
+	for _, w := range warnings {
+		c.Assert(w.Service, Equals, "my_tail_cmd")
+
+		if w.Rule == LINT_LOW_NOFILE {
+			c.Assert(w.Severity, Equals, SEVERITY_ERROR)
+		}
+	}
+
+	// Default kill timeout from configuration doesn't disable rule
+	app, err = Read("../testdata/procfile_v2_lint", &Config{KillTimeout: 30})
+
+	c.Assert(err, IsNil)
+	c.Assert(app.Services[0].Options.KillTimeout, Equals, 30)
+	c.Assert(app.Lint(nil)[0].Rule, Equals, LINT_NO_KILL_TIMEOUT)
+
+	app, err = parseV2Procfile([]byte("version: 2\nworking_directory: /srv/app\nkill_timeout: 10\ncommands:\n  web:\n    command: /bin/web\n    respawn: false\n"), &Config{KillTimeout: 30})
+
+	c.Assert(err, IsNil)
+	c.Assert(app.Lint(nil), HasLen, 0)
+
+	c.Assert(parseMemorySize("512M"), Equals, uint64(512*1024*1024))
+	c.Assert(parseMemorySize("1024"), Equals, uint64(1024))
+	c.Assert(parseMemorySize("50%"), Equals, uint64(0))
+	c.Assert(parseMemorySize("infinity"), Equals, uint64(0))
+	c.Assert(countAffinityCPUs("0-3, 5,7 8"), Equals, 7)
+	c.Assert(countAffinityCPUs("0-3,2"), Equals, 4)
+	c.Assert(countAffinityCPUs("3-1"), Equals, 0)
+	c.Assert(countAffinityCPUs("4-7,0-5,1"), Equals, 8)
+	c.Assert(countAffinityCPUs("0-8191"), Equals, 8192)
+	c.Assert(countAffinityCPUs("0-9223372036854775807"), Equals, 0)
+
+	options := &ServiceOptions{Resources: &Resources{CPUAffinity: "0-100000"}}
+
+	c.Assert(options.Validate().Error(" "), Matches, ".*resources.cpu_affinity: must contain CPU numbers or ranges of CPU numbers in range 0-8191.*")
+}
+
 func (s *ProcfileSuite) TestProcV2Parsing(c *C) {
 	app, err := Read("../testdata/procfile_v2", s.Config)
 
@@ -134,7 +1964,7 @@ func (s *ProcfileSuite) TestProcV2Parsing(c *C) {
 			c.Assert(service.Options.RespawnCount, Equals, 7)
 			c.Assert(service.Options.RespawnInterval, Equals, 22)
 			c.Assert(service.Options.IsRespawnEnabled, Equals, false)
-			c.Assert(service.Options.EnvFile, Equals, "shared/env.file")
+			c.Assert(service.Options.EnvFiles, DeepEquals, []EnvFile{{Path: "shared/env.file", Required: true}})
 			c.Assert(service.Options.EnvString(), Equals, "RAILS_ENV=production TEST=true")
 			c.Assert(service.Options.LimitFile, Equals, 8192)
 			c.Assert(service.Options.LimitProc, Equals, 8192)
@@ -206,3 +2036,25 @@ func (s *ProcfileSuite) TestProcV2Parsing(c *C) {
 
 	c.Assert(app.Validate(), HasLen, 0)
 }
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// assertAppsEqual checks that applications are equal regardless of order
+// of services, information about source and deferred errors
+func assertAppsEqual(c *C, obtained, expected *Application) {
+	for _, app := range []*Application{obtained, expected} {
+		app.source, app.deferredErrs = nil, nil
+
+		sort.Slice(app.Services, func(i, j int) bool {
+			return app.Services[i].Name < app.Services[j].Name
+		})
+	}
+
+	c.Assert(obtained, DeepEquals, expected)
+}
+
+// writeFile writes data to file and creates all parent directories
+func writeFile(c *C, path, data string) {
+	c.Assert(os.MkdirAll(filepath.Dir(path), 0755), IsNil)
+	c.Assert(os.WriteFile(path, []byte(data), 0644), IsNil)
+}
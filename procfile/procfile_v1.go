@@ -27,11 +27,14 @@ func parseV1Procfile(data []byte, config *Config) (*Application, error) {
 	log.Debug("Parsing procfile as v1")
 
 	var services []*Service
+	var lineNum int
 
+	src := newEmptySource(data)
 	reader := bytes.NewReader(data)
 	scanner := bufio.NewScanner(reader)
 
 	for scanner.Scan() {
+		lineNum++
 		line := strings.TrimSpace(scanner.Text())
 
 		switch {
@@ -43,9 +46,15 @@ func parseV1Procfile(data []byte, config *Config) (*Application, error) {
 			service, err := parseV1Line(line)
 
 			if err != nil {
-				return nil, err
+				return nil, &Error{
+					Pos:     Position{Line: lineNum, Column: 1},
+					Rule:    RULE_SYNTAX,
+					Message: err.Error(),
+				}
 			}
 
+			src.add("commands."+service.Name, Position{Line: lineNum, Column: 1})
+
 			if service.Options.LimitFile == 0 && config.LimitFile != 0 {
 				service.Options.LimitFile = config.LimitFile
 			}
@@ -73,6 +82,8 @@ func parseV1Procfile(data []byte, config *Config) (*Application, error) {
 		Group:       config.Group,
 		WorkingDir:  config.WorkingDir,
 		Services:    services,
+		Logrotate:   newLogrotate(config),
+		source:      src,
 	}
 
 	addCrossLink(app)
@@ -96,14 +107,20 @@ func parseV1Line(line string) (*Service, error) {
 func parseV1Command(name, command string) (*Service, error) {
 	var service = &Service{Name: name, Options: &ServiceOptions{}}
 
-	cmdSlice := splitV1Command(command)
+	cmdSlice, err := splitV1Command(command)
+
+	if err != nil {
+		return nil, fmt.Errorf("Procfile v1 command misformatted: %v", err)
+	}
+
+	tokens, _ := Tokenize(cmdSlice[0])
 
-	if strings.HasPrefix(cmdSlice[0], "cd") {
-		if len(cmdSlice) == 1 {
+	if tokens[0].IsWord("cd") {
+		if len(cmdSlice) == 1 || len(tokens) != 2 {
 			return nil, fmt.Errorf("Procfile v1 command misformatted: %s", command)
 		}
 
-		service.Options.WorkingDir = strings.TrimPrefix(cmdSlice[0], "cd ")
+		service.Options.WorkingDir = tokens[1].Value
 		cmdSlice = cmdSlice[1:]
 	}
 
@@ -115,16 +132,18 @@ func parseV1Command(name, command string) (*Service, error) {
 		log  string
 	)
 
+	// Parts of command are already tokenized by splitV1Command, so they
+	// can't contain syntax errors
 	switch len(cmdSlice) {
 	case 3:
-		pre, _, _ = parseCommand(cmdSlice[0])
-		cmd, log, env = parseCommand(cmdSlice[1])
-		post, _, _ = parseCommand(cmdSlice[2])
+		pre, _, _, _ = parseCommand(cmdSlice[0])
+		cmd, log, env, _ = parseCommand(cmdSlice[1])
+		post, _, _, _ = parseCommand(cmdSlice[2])
 	case 2:
-		pre, _, _ = parseCommand(cmdSlice[0])
-		cmd, log, env = parseCommand(cmdSlice[1])
+		pre, _, _, _ = parseCommand(cmdSlice[0])
+		cmd, log, env, _ = parseCommand(cmdSlice[1])
 	default:
-		cmd, log, env = parseCommand(cmdSlice[0])
+		cmd, log, env, _ = parseCommand(cmdSlice[0])
 	}
 
 	service.Cmd = cmd
@@ -136,13 +155,30 @@ func parseV1Command(name, command string) (*Service, error) {
 	return service, nil
 }
 
-// splitV1Cmd split command and format command
-func splitV1Command(cmd string) []string {
+// splitV1Command splits command to parts chained with && operator
+func splitV1Command(cmd string) ([]string, error) {
 	var result []string
 
-	for _, cmdPart := range strings.Split(cmd, "&&") {
-		result = append(result, strings.TrimSpace(cmdPart))
+	tokens, err := Tokenize(cmd)
+
+	if err != nil {
+		return nil, err
+	}
+
+	start := 0
+
+	for index := 0; index <= len(tokens); index++ {
+		if index < len(tokens) && !tokens[index].IsOperator("&&") {
+			continue
+		}
+
+		if index == start {
+			return nil, fmt.Errorf("Empty command in chain")
+		}
+
+		result = append(result, cmd[tokens[start].Pos:tokens[index-1].End])
+		start = index + 1
 	}
 
-	return result
+	return result, nil
 }
@@ -8,9 +8,15 @@ package procfile
 
 import (
 	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"sort"
 	"strings"
 
+	"github.com/essentialkaos/ek/v13/errors"
 	"github.com/essentialkaos/ek/v13/log"
+	"github.com/essentialkaos/ek/v13/spellcheck"
 	"github.com/essentialkaos/ek/v13/strutil"
 
 	"github.com/essentialkaos/go-simpleyaml/v2"
@@ -18,18 +24,64 @@ import (
 
 // ////////////////////////////////////////////////////////////////////////////////// //
 
+// Known properties of v2 procfile
+var (
+	v2AppProps = []string{
+		"version", "extends", "include", "start_on_runlevel", "stop_on_runlevel", "start_on_device",
+		"strong_dependencies", "depends", "logrotate", "vars",
+	}
+
+	v2SectionProps = []string{"commands", "schedules", "tasks", "profiles"}
+
+	v2ServiceProps = []string{"command", "pre", "post", "port", "priority", "depends_on", "healthcheck", "sockets"}
+
+	v2OptionsProps = []string{
+		"working_directory", "user", "group", "log", "kill_timeout", "kill_signal", "kill_mode",
+		"reload_signal", "type", "notify_access", "pid_file", "watchdog_sec", "count", "env",
+		"env_file", "respawn", "limits", "resources", "security", "secrets",
+	}
+
+	v2RespawnProps = []string{"count", "interval", "delay"}
+
+	v2LimitsProps = []string{"nofile", "nproc", "memlock"}
+
+	v2ResourcesProps = []string{
+		"cpu_weight", "startup_cpu_weight", "cpu_quota", "cpu_affinity",
+		"memory_low", "memory_high", "memory_max", "memory_swap_max", "task_max",
+		"io_weight", "startup_io_weight", "io_device_weight", "io_read_bandwidth_max",
+		"io_write_bandwidth_max", "io_read_iops_max", "io_write_iops_max",
+		"ip_address_allow", "ip_address_deny",
+	}
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
 // parseV2Procfile parse v2 procfile data
 func parseV2Procfile(data []byte, config *Config) (*Application, error) {
 	var err error
 
 	log.Debug("Parsing procfile as v2")
 
+	if config.Profile != "" {
+		return parseV2Profile(data, config)
+	}
+
+	src, srcErr := newSource(data)
 	yaml, err := simpleyaml.NewYaml(data)
 
 	if err != nil {
+		if srcErr != nil {
+			return nil, srcErr
+		}
+
 		return nil, err
 	}
 
+	return parseV2Document(yaml, src, config)
+}
+
+// parseV2Document parse v2 procfile document
+func parseV2Document(yaml *simpleyaml.Yaml, src *source, config *Config) (*Application, error) {
 	commands, err := yaml.Get("commands").Map()
 
 	if err != nil {
@@ -39,9 +91,12 @@ func parseV2Procfile(data []byte, config *Config) (*Application, error) {
 	services, err := parseV2Services(yaml, commands, config)
 
 	if err != nil {
+		src.annotate("", err)
 		return nil, err
 	}
 
+	sortV2Services(services, src)
+
 	app := &Application{
 		ProcVersion: 2,
 		Name:        config.Name,
@@ -51,17 +106,28 @@ func parseV2Procfile(data []byte, config *Config) (*Application, error) {
 		StopLevel:   3,
 		WorkingDir:  config.WorkingDir,
 		Services:    services,
+		source:      src,
 	}
 
 	if yaml.IsExist("working_directory") {
 		app.WorkingDir = yamlGetSafe(yaml, "working_directory")
 	}
 
+	if yaml.IsExist("user") {
+		app.User = yamlGetSafe(yaml, "user")
+	}
+
+	if yaml.IsExist("group") {
+		app.Group = yamlGetSafe(yaml, "group")
+	}
+
 	if yaml.IsExist("start_on_runlevel") {
 		app.StartLevel, err = yaml.Get("start_on_runlevel").Int()
 
 		if err != nil {
-			return nil, fmt.Errorf("Can't parse start_on_runlevel value: %v", err)
+			err = formatPropError("start_on_runlevel", err)
+			src.annotate("", err)
+			return nil, err
 		}
 	}
 
@@ -69,7 +135,9 @@ func parseV2Procfile(data []byte, config *Config) (*Application, error) {
 		app.StopLevel, err = yaml.Get("stop_on_runlevel").Int()
 
 		if err != nil {
-			return nil, fmt.Errorf("Can't parse stop_on_runlevel value: %v", err)
+			err = formatPropError("stop_on_runlevel", err)
+			src.annotate("", err)
+			return nil, err
 		}
 	}
 
@@ -81,7 +149,9 @@ func parseV2Procfile(data []byte, config *Config) (*Application, error) {
 		app.StrongDependencies, err = yaml.Get("strong_dependencies").Bool()
 
 		if err != nil {
-			return nil, fmt.Errorf("Can't parse strong_dependencies value: %v", err)
+			err = formatPropError("strong_dependencies", err)
+			src.annotate("", err)
+			return nil, err
 		}
 	}
 
@@ -90,17 +160,82 @@ func parseV2Procfile(data []byte, config *Config) (*Application, error) {
 		app.Depends = strutil.Fields(deps)
 	}
 
+	app.Logrotate, err = parseV2Logrotate(yaml, config)
+
+	if err != nil {
+		src.annotate("", err)
+		return nil, err
+	}
+
+	app.Schedules, err = parseV2Schedules(yaml, src)
+
+	if err != nil {
+		src.annotate("", err)
+		return nil, err
+	}
+
+	app.Tasks, err = parseV2Tasks(yaml, src)
+
+	if err != nil {
+		src.annotate("", err)
+		return nil, err
+	}
+
+	app.Profile = config.Profile
+	app.Profiles, err = parseV2Profiles(yaml)
+
+	if err != nil {
+		src.annotate("", err)
+		return nil, err
+	}
+
+	vars, err := parseV2Vars(yaml)
+
+	if err != nil {
+		src.annotate("", err)
+		return nil, err
+	}
+
+	app.deferredErrs = append(app.deferredErrs, interpolate(app, vars, config.UseEnvVars)...)
+
+	if config.IsStrict {
+		app.deferredErrs = append(app.deferredErrs, checkV2Props(yaml)...)
+	}
+
 	addCrossLink(app)
 
 	return app, nil
 }
 
+// parseV2Vars parse variables defined in yaml based procfile
+func parseV2Vars(yaml *simpleyaml.Yaml) (map[string]string, error) {
+	if !yaml.IsExist("vars") {
+		return nil, nil
+	}
+
+	vars, err := yaml.Get("vars").Map()
+
+	if err != nil {
+		return nil, formatPropError("vars", err)
+	}
+
+	result := convertMapType(vars)
+
+	for _, name := range slices.Sorted(maps.Keys(result)) {
+		if !regexp.MustCompile(REGEXP_VAR_NAME).MatchString(name) {
+			return nil, &Error{Rule: RULE_INVALID_NAME, Path: "vars." + name, Message: "variable name is misformatted"}
+		}
+	}
+
+	return result, nil
+}
+
 // parseV2Services parse services sections in yaml based procfile
 func parseV2Services(yaml *simpleyaml.Yaml, commands map[interface{}]interface{}, config *Config) ([]*Service, error) {
 	var services []*Service
 
 	commonOptions := &ServiceOptions{}
-	err := parseV2Options(commonOptions, yaml)
+	err := parseV2Options(commonOptions, yaml, "")
 
 	if err != nil {
 		return nil, err
@@ -113,14 +248,15 @@ func parseV2Services(yaml *simpleyaml.Yaml, commands map[interface{}]interface{}
 		}
 
 		serviceYaml := yaml.GetPath("commands", service.Name)
+		prefix := "commands." + service.Name + "."
 
-		err := parseV2Commands(service, serviceYaml)
+		err := parseV2Commands(service, serviceYaml, prefix)
 
 		if err != nil {
 			return nil, err
 		}
 
-		err = parseV2Options(service.Options, serviceYaml)
+		err = parseV2Options(service.Options, serviceYaml, prefix)
 
 		if err != nil {
 			return nil, err
@@ -135,32 +271,155 @@ func parseV2Services(yaml *simpleyaml.Yaml, commands map[interface{}]interface{}
 	return services, nil
 }
 
+// sortV2Services sorts services by priority, services with the same priority
+// are kept in order of definition in procfile
+func sortV2Services(services []*Service, src *source) {
+	sort.SliceStable(services, func(i, j int) bool {
+		if services[i].Priority != services[j].Priority {
+			return services[i].Priority < services[j].Priority
+		}
+
+		return src.orderOf("commands."+services[i].Name) < src.orderOf("commands."+services[j].Name)
+	})
+}
+
 // parseV2Commands parse service commands
-func parseV2Commands(service *Service, yaml *simpleyaml.Yaml) error {
-	var cmd, log string
+func parseV2Commands(service *Service, yaml *simpleyaml.Yaml, prefix string) error {
+	var err error
+	var log string
+
+	service.Cmd, service.CmdArgs, err = parseV2CommandValue(yaml, "command", prefix)
+
+	if err != nil {
+		return err
+	}
 
-	cmd = yamlGetSafe(yaml, "command")
-	cmd, log, _ = parseCommand(cmd)
+	if service.CmdArgs == nil {
+		service.Cmd, log, _, err = parseCommand(service.Cmd)
+
+		if err != nil {
+			return formatCommandError(prefix+"command", err)
+		}
+	}
 
 	if log != "" {
 		service.Options.LogFile = log
 	}
 
-	service.Cmd = cmd
-
 	if yaml.IsExist("pre") {
-		service.PreCmd = yamlGetSafe(yaml, "pre")
+		service.PreCmd, service.PreCmdArgs, err = parseV2CommandValue(yaml, "pre", prefix)
+
+		if err != nil {
+			return err
+		}
 	}
 
 	if yaml.IsExist("post") {
-		service.PostCmd = yamlGetSafe(yaml, "post")
+		service.PostCmd, service.PostCmdArgs, err = parseV2CommandValue(yaml, "post", prefix)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if yaml.IsExist("port") {
+		service.Port, err = yaml.Get("port").Int()
+
+		if err != nil {
+			return formatPropError(prefix+"port", err)
+		}
+	}
+
+	if yaml.IsExist("priority") {
+		service.Priority, err = yaml.Get("priority").Int()
+
+		if err != nil {
+			return formatPropError(prefix+"priority", err)
+		}
+	}
+
+	if yaml.IsExist("depends_on") {
+		service.DependsOn, err = parseV2DependsOn(yaml, prefix)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if yaml.IsExist("healthcheck") {
+		service.HealthCheck, err = parseV2HealthCheck(yaml.Get("healthcheck"), prefix)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if yaml.IsExist("sockets") {
+		service.Sockets, err = parseV2Sockets(yaml.Get("sockets"), prefix)
+
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
+// parseV2DependsOn parse list of services which must be started before service
+func parseV2DependsOn(yaml *simpleyaml.Yaml, prefix string) ([]string, error) {
+	if !yaml.Get("depends_on").IsArray() {
+		return strutil.Fields(yamlGetSafe(yaml, "depends_on")), nil
+	}
+
+	items, _ := yaml.Get("depends_on").Array()
+	result := make([]string, len(items))
+
+	for index, item := range items {
+		switch item.(type) {
+		case nil, []interface{}, map[interface{}]interface{}:
+			return nil, &Error{Rule: RULE_INVALID_TYPE, Path: prefix + "depends_on", Message: "service names must be strings"}
+		}
+
+		result[index] = fmt.Sprint(item)
+	}
+
+	return result, nil
+}
+
+// parseV2CommandValue parse command defined as string or as list of arguments
+func parseV2CommandValue(yaml *simpleyaml.Yaml, prop, prefix string) (string, []string, error) {
+	if !yaml.Get(prop).IsArray() {
+		command := yamlGetSafe(yaml, prop)
+
+		if _, err := Tokenize(command); err != nil {
+			return "", nil, formatCommandError(prefix+prop, err)
+		}
+
+		return command, nil, nil
+	}
+
+	items, _ := yaml.Get(prop).Array()
+
+	if len(items) == 0 {
+		return "", nil, &Error{Rule: RULE_INVALID_VALUE, Path: prefix + prop, Message: "list of arguments can't be empty"}
+	}
+
+	args := make([]string, len(items))
+
+	for index, item := range items {
+		switch item.(type) {
+		case nil, []interface{}, map[interface{}]interface{}:
+			return "", nil, &Error{Rule: RULE_INVALID_TYPE, Path: prefix + prop, Message: "arguments must be strings"}
+		}
+
+		args[index] = fmt.Sprint(item)
+	}
+
+	return QuoteArgs(args), args, nil
+}
+
 // parseV2Options parse service options in yaml based procfile
-func parseV2Options(options *ServiceOptions, yaml *simpleyaml.Yaml) error {
+func parseV2Options(options *ServiceOptions, yaml *simpleyaml.Yaml, prefix string) error {
 	var err error
 
 	options.Env = make(map[string]string)
@@ -170,6 +429,14 @@ func parseV2Options(options *ServiceOptions, yaml *simpleyaml.Yaml) error {
 		options.WorkingDir = yamlGetSafe(yaml, "working_directory")
 	}
 
+	if yaml.IsExist("user") {
+		options.User = yamlGetSafe(yaml, "user")
+	}
+
+	if yaml.IsExist("group") {
+		options.Group = yamlGetSafe(yaml, "group")
+	}
+
 	if yaml.IsExist("log") {
 		options.LogFile = yamlGetSafe(yaml, "log")
 	}
@@ -178,7 +445,7 @@ func parseV2Options(options *ServiceOptions, yaml *simpleyaml.Yaml) error {
 		options.KillTimeout, err = yaml.Get("kill_timeout").Int()
 
 		if err != nil {
-			return formatPropError("kill_timeout", err)
+			return formatPropError(prefix+"kill_timeout", err)
 		}
 	}
 
@@ -194,11 +461,31 @@ func parseV2Options(options *ServiceOptions, yaml *simpleyaml.Yaml) error {
 		options.ReloadSignal = yamlGetSafe(yaml, "reload_signal")
 	}
 
+	if yaml.IsExist("type") {
+		options.Type = yamlGetSafe(yaml, "type")
+	}
+
+	if yaml.IsExist("notify_access") {
+		options.NotifyAccess = yamlGetSafe(yaml, "notify_access")
+	}
+
+	if yaml.IsExist("pid_file") {
+		options.PIDFile = yamlGetSafe(yaml, "pid_file")
+	}
+
+	if yaml.IsExist("watchdog_sec") {
+		options.WatchdogSec, err = yaml.Get("watchdog_sec").Int()
+
+		if err != nil {
+			return formatPropError(prefix+"watchdog_sec", err)
+		}
+	}
+
 	if yaml.IsExist("count") {
 		options.Count, err = yaml.Get("count").Int()
 
 		if err != nil {
-			return formatPropError("count", err)
+			return formatPropError(prefix+"count", err)
 		}
 	}
 
@@ -206,14 +493,18 @@ func parseV2Options(options *ServiceOptions, yaml *simpleyaml.Yaml) error {
 		env, err := yaml.Get("env").Map()
 
 		if err != nil {
-			return formatPropError("env", err)
+			return formatPropError(prefix+"env", err)
 		}
 
 		options.Env = convertMapType(env)
 	}
 
 	if yaml.IsExist("env_file") {
-		options.EnvFile = yamlGetSafe(yaml, "env_file")
+		options.EnvFiles, err = parseV2EnvFiles(yaml, prefix)
+
+		if err != nil {
+			return err
+		}
 	}
 
 	if yaml.IsPathExist("respawn", "count") ||
@@ -223,7 +514,7 @@ func parseV2Options(options *ServiceOptions, yaml *simpleyaml.Yaml) error {
 			options.RespawnCount, err = yaml.Get("respawn").Get("count").Int()
 
 			if err != nil {
-				return formatPropError("respawn:count", err)
+				return formatPropError(prefix+"respawn.count", err)
 			}
 		}
 
@@ -231,7 +522,7 @@ func parseV2Options(options *ServiceOptions, yaml *simpleyaml.Yaml) error {
 			options.RespawnInterval, err = yaml.Get("respawn").Get("interval").Int()
 
 			if err != nil {
-				return formatPropError("respawn:interval", err)
+				return formatPropError(prefix+"respawn.interval", err)
 			}
 		}
 
@@ -239,7 +530,7 @@ func parseV2Options(options *ServiceOptions, yaml *simpleyaml.Yaml) error {
 			options.RespawnDelay, err = yaml.Get("respawn").Get("delay").Int()
 
 			if err != nil {
-				return formatPropError("respawn:delay", err)
+				return formatPropError(prefix+"respawn.delay", err)
 			}
 		}
 
@@ -247,7 +538,7 @@ func parseV2Options(options *ServiceOptions, yaml *simpleyaml.Yaml) error {
 		options.IsRespawnEnabled, err = yaml.Get("respawn").Bool()
 
 		if err != nil {
-			return formatPropError("respawn", err)
+			return formatPropError(prefix+"respawn", err)
 		}
 	}
 
@@ -256,7 +547,7 @@ func parseV2Options(options *ServiceOptions, yaml *simpleyaml.Yaml) error {
 			options.LimitFile, err = yaml.Get("limits").Get("nofile").Int()
 
 			if err != nil {
-				return formatPropError("limits:nofile", err)
+				return formatPropError(prefix+"limits.nofile", err)
 			}
 		}
 
@@ -264,7 +555,7 @@ func parseV2Options(options *ServiceOptions, yaml *simpleyaml.Yaml) error {
 			options.LimitProc, err = yaml.Get("limits").Get("nproc").Int()
 
 			if err != nil {
-				return formatPropError("limits:nproc", err)
+				return formatPropError(prefix+"limits.nproc", err)
 			}
 		}
 
@@ -272,13 +563,29 @@ func parseV2Options(options *ServiceOptions, yaml *simpleyaml.Yaml) error {
 			options.LimitMemlock, err = yaml.Get("limits").Get("memlock").Int()
 
 			if err != nil {
-				return formatPropError("limits:memlock", err)
+				return formatPropError(prefix+"limits.memlock", err)
 			}
 		}
 	}
 
 	if yaml.IsExist("resources") {
-		options.Resources, err = parseV2Resources(yaml.Get("resources"))
+		options.Resources, err = parseV2Resources(yaml.Get("resources"), prefix+"resources.")
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if yaml.IsExist("security") {
+		options.Security, err = parseV2Security(yaml.Get("security"), prefix+"security.")
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if yaml.IsExist("secrets") {
+		options.Secrets, err = parseV2Secrets(yaml.Get("secrets"), prefix)
 
 		if err != nil {
 			return err
@@ -288,8 +595,65 @@ func parseV2Options(options *ServiceOptions, yaml *simpleyaml.Yaml) error {
 	return nil
 }
 
+// parseV2EnvFiles parse env files defined as path, map with path and
+// required flag or list of them
+func parseV2EnvFiles(yaml *simpleyaml.Yaml, prefix string) ([]EnvFile, error) {
+	var items []*simpleyaml.Yaml
+
+	envFile := yaml.Get("env_file")
+
+	if envFile.IsArray() {
+		list, _ := envFile.Array()
+
+		for index := range list {
+			items = append(items, envFile.GetByIndex(index))
+		}
+	} else {
+		items = append(items, envFile)
+	}
+
+	if len(items) == 0 {
+		return nil, &Error{Rule: RULE_INVALID_VALUE, Path: prefix + "env_file", Message: "list of files can't be empty"}
+	}
+
+	var result []EnvFile
+
+	for _, item := range items {
+		file := EnvFile{Required: true}
+
+		if item.IsMap() {
+			if !item.IsExist("path") {
+				return nil, &Error{Rule: RULE_INVALID_VALUE, Path: prefix + "env_file", Message: "path to file is not defined"}
+			}
+
+			file.Path = yamlGetSafe(item, "path")
+
+			if item.IsExist("required") {
+				required, err := item.Get("required").Bool()
+
+				if err != nil {
+					return nil, formatPropError(prefix+"env_file", err)
+				}
+
+				file.Required = required
+			}
+		} else {
+			switch item.Interface().(type) {
+			case nil, []interface{}:
+				return nil, &Error{Rule: RULE_INVALID_TYPE, Path: prefix + "env_file", Message: "expected path or map with path"}
+			}
+
+			file.Path = strings.Trim(item.Dump(), "\n\r")
+		}
+
+		result = append(result, file)
+	}
+
+	return result, nil
+}
+
 // parseV2Resources parse service resources options in yaml based procfile
-func parseV2Resources(yaml *simpleyaml.Yaml) (*Resources, error) {
+func parseV2Resources(yaml *simpleyaml.Yaml, prefix string) (*Resources, error) {
 	var err error
 
 	resources := &Resources{}
@@ -298,7 +662,7 @@ func parseV2Resources(yaml *simpleyaml.Yaml) (*Resources, error) {
 		resources.CPUWeight, err = yaml.Get("cpu_weight").Int()
 
 		if err != nil {
-			return nil, formatPropError("resources:cpu_weight", err)
+			return nil, formatPropError(prefix+"cpu_weight", err)
 		}
 	}
 
@@ -306,7 +670,7 @@ func parseV2Resources(yaml *simpleyaml.Yaml) (*Resources, error) {
 		resources.StartupCPUWeight, err = yaml.Get("startup_cpu_weight").Int()
 
 		if err != nil {
-			return nil, formatPropError("resources:startup_cpu_weight", err)
+			return nil, formatPropError(prefix+"startup_cpu_weight", err)
 		}
 	}
 
@@ -314,7 +678,7 @@ func parseV2Resources(yaml *simpleyaml.Yaml) (*Resources, error) {
 		resources.CPUQuota, err = yaml.Get("cpu_quota").Int()
 
 		if err != nil {
-			return nil, formatPropError("resources:cpu_quota", err)
+			return nil, formatPropError(prefix+"cpu_quota", err)
 		}
 	}
 
@@ -342,7 +706,7 @@ func parseV2Resources(yaml *simpleyaml.Yaml) (*Resources, error) {
 		resources.TasksMax, err = yaml.Get("task_max").Int()
 
 		if err != nil {
-			return nil, formatPropError("resources:task_max", err)
+			return nil, formatPropError(prefix+"task_max", err)
 		}
 	}
 
@@ -350,7 +714,7 @@ func parseV2Resources(yaml *simpleyaml.Yaml) (*Resources, error) {
 		resources.IOWeight, err = yaml.Get("io_weight").Int()
 
 		if err != nil {
-			return nil, formatPropError("resources:io_weight", err)
+			return nil, formatPropError(prefix+"io_weight", err)
 		}
 	}
 
@@ -358,7 +722,7 @@ func parseV2Resources(yaml *simpleyaml.Yaml) (*Resources, error) {
 		resources.StartupIOWeight, err = yaml.Get("startup_io_weight").Int()
 
 		if err != nil {
-			return nil, formatPropError("resources:startup_io_weight", err)
+			return nil, formatPropError(prefix+"startup_io_weight", err)
 		}
 	}
 
@@ -393,12 +757,147 @@ func parseV2Resources(yaml *simpleyaml.Yaml) (*Resources, error) {
 	return resources, nil
 }
 
+// checkV2Props checks procfile for unknown properties on application
+// and service levels
+func checkV2Props(yaml *simpleyaml.Yaml) errors.Errors {
+	var errs errors.Bundle
+
+	errs.Add(checkUnknownProps(yaml, "", v2AppProps, v2OptionsProps, v2SectionProps))
+	errs.Add(checkV2OptionsProps(yaml, ""))
+
+	if yaml.Get("logrotate").IsMap() {
+		errs.Add(checkUnknownProps(yaml.Get("logrotate"), "logrotate.", v2LogrotateProps))
+	}
+
+	services, _ := yaml.Get("commands").GetMapKeys()
+
+	sort.Strings(services)
+
+	for _, service := range services {
+		serviceYaml := yaml.GetPath("commands", service)
+		prefix := "commands." + service + "."
+
+		errs.Add(checkUnknownProps(serviceYaml, prefix, v2ServiceProps, v2OptionsProps))
+		errs.Add(checkV2OptionsProps(serviceYaml, prefix))
+		errs.Add(checkUnknownProps(serviceYaml.Get("healthcheck"), prefix+"healthcheck.", v2HealthCheckProps))
+		errs.Add(checkUnknownProps(serviceYaml.Get("sockets"), prefix+"sockets.", v2SocketsProps))
+	}
+
+	jobs, _ := yaml.Get("schedules").GetMapKeys()
+
+	sort.Strings(jobs)
+
+	for _, job := range jobs {
+		errs.Add(checkUnknownProps(yaml.GetPath("schedules", job), "schedules."+job+".", v2ScheduleProps, v2JobOptionsProps))
+	}
+
+	tasks, _ := yaml.Get("tasks").GetMapKeys()
+
+	sort.Strings(tasks)
+
+	for _, task := range tasks {
+		errs.Add(checkUnknownProps(yaml.GetPath("tasks", task), "tasks."+task+".", v2TaskProps, v2JobOptionsProps))
+	}
+
+	return errs.All()
+}
+
+// checkV2OptionsProps checks nested options sections for unknown properties
+func checkV2OptionsProps(yaml *simpleyaml.Yaml, prefix string) errors.Errors {
+	var errs errors.Bundle
+
+	if yaml.Get("respawn").IsMap() {
+		errs.Add(checkUnknownProps(yaml.Get("respawn"), prefix+"respawn.", v2RespawnProps))
+	}
+
+	errs.Add(checkUnknownProps(yaml.Get("limits"), prefix+"limits.", v2LimitsProps))
+	errs.Add(checkUnknownProps(yaml.Get("resources"), prefix+"resources.", v2ResourcesProps))
+	errs.Add(checkUnknownProps(yaml.Get("security"), prefix+"security.", v2SecurityProps))
+
+	secrets, _ := yaml.Get("secrets").GetMapKeys()
+
+	for _, name := range secrets {
+		if yaml.GetPath("secrets", name).IsMap() {
+			errs.Add(checkUnknownProps(yaml.GetPath("secrets", name), prefix+"secrets."+name+".", v2SecretProps))
+		}
+	}
+
+	return errs.All()
+}
+
+// checkUnknownProps returns errors for every property which is not present
+// in the given lists of known properties
+func checkUnknownProps(yaml *simpleyaml.Yaml, prefix string, known ...[]string) errors.Errors {
+	var errs errors.Bundle
+	var props []string
+
+	for _, list := range known {
+		props = append(props, list...)
+	}
+
+	keys, _ := yaml.GetMapKeys()
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if slices.Contains(props, key) {
+			continue
+		}
+
+		suggestion := suggestProp(key, props)
+
+		if suggestion == "" {
+			errs.Add(&Error{Rule: RULE_UNKNOWN_PROPERTY, Path: prefix + key, Message: "unknown property"})
+		} else {
+			errs.Add(&Error{
+				Rule:    RULE_UNKNOWN_PROPERTY,
+				Path:    prefix + key,
+				Message: fmt.Sprintf("unknown property (did you mean \"%s\"?)", suggestion),
+			})
+		}
+	}
+
+	return errs.All()
+}
+
+// suggestProp returns the closest known property for given misspelled
+// property name
+func suggestProp(prop string, props []string) string {
+	var result string
+
+	minDist := 3
+
+	for _, p := range props {
+		dist := spellcheck.Distance(prop, p)
+
+		if dist < minDist {
+			minDist, result = dist, p
+		}
+	}
+
+	return result
+}
+
 // yamlGetSafe returns string from YAML without potentially unsafe symbols
 func yamlGetSafe(yaml *simpleyaml.Yaml, propName string) string {
 	return strings.Trim(yaml.Get(propName).Dump(), "\n\r")
 }
 
 // formatPropError format property parsing error
-func formatPropError(prop string, err error) error {
-	return fmt.Errorf("Can't parse \"%s\" value: %v", prop, err)
+func formatPropError(path string, err error) error {
+	switch err {
+	case simpleyaml.ErrIntTypeAssertion:
+		return &Error{Rule: RULE_INVALID_TYPE, Path: path, Message: "expected integer"}
+	case simpleyaml.ErrBoolTypeAssertion:
+		return &Error{Rule: RULE_INVALID_TYPE, Path: path, Message: "expected boolean"}
+	case simpleyaml.ErrMapTypeAssertion:
+		return &Error{Rule: RULE_INVALID_TYPE, Path: path, Message: "expected map"}
+	}
+
+	return &Error{Rule: RULE_INVALID_TYPE, Path: path, Message: fmt.Sprintf("can't parse value: %v", err)}
+}
+
+// formatCommandError formats error of command parsing
+func formatCommandError(path string, err error) error {
+	return &Error{Rule: RULE_INVALID_VALUE, Path: path, Message: fmt.Sprintf("can't parse command: %v", err)}
 }
@@ -0,0 +1,128 @@
+package procfile
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                           Copyright (c) 2006-2024 FUNBOX                           //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/essentialkaos/go-simpleyaml/v2"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// parseV2Profile parse v2 procfile data with applied profile
+func parseV2Profile(data []byte, config *Config) (*Application, error) {
+	var doc yaml.Node
+
+	err := yaml.Unmarshal(data, &doc)
+
+	if err != nil {
+		return nil, parseYAMLError(err)
+	}
+
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("Commands missing in Procfile")
+	}
+
+	return parseV2Node(data, doc.Content[0], nil, config)
+}
+
+// parseV2Node parse v2 procfile document node (with merged included files)
+// and applies profile to it
+func parseV2Node(data []byte, root *yaml.Node, files map[*yaml.Node]string, config *Config) (*Application, error) {
+	expandAliases(root)
+
+	if config.Profile != "" {
+		profile, err := getProfile(root, config.Profile, files)
+
+		if err != nil {
+			return nil, err
+		}
+
+		root = mergeNodes(root, profile)
+	}
+
+	merged, err := yaml.Marshal(root)
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't encode merged procfile: %v", err)
+	}
+
+	mergedYaml, err := simpleyaml.NewYaml(merged)
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't encode merged procfile: %v", err)
+	}
+
+	src := newEmptySource(data)
+	src.files = files
+	src.index(root, "")
+
+	return parseV2Document(mergedYaml, src, config)
+}
+
+// parseV2Profiles returns names of all profiles defined in procfile
+func parseV2Profiles(yaml *simpleyaml.Yaml) ([]string, error) {
+	if !yaml.IsExist("profiles") {
+		return nil, nil
+	}
+
+	profiles, err := yaml.Get("profiles").GetMapKeys()
+
+	if err != nil {
+		return nil, formatPropError("profiles", err)
+	}
+
+	sort.Strings(profiles)
+
+	return profiles, nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// getProfile returns node of profile with given name
+func getProfile(root *yaml.Node, name string, files map[*yaml.Node]string) (*yaml.Node, error) {
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, profiles := root.Content[i], root.Content[i+1]
+
+		if key.Value != "profiles" {
+			continue
+		}
+
+		for j := 0; j+1 < len(profiles.Content); j += 2 {
+			if profiles.Content[j].Value != name {
+				continue
+			}
+
+			profile := profiles.Content[j+1]
+
+			if profile.Kind != yaml.MappingNode {
+				return nil, &Error{
+					Pos:     Position{File: files[profiles.Content[j]], Line: profiles.Content[j].Line, Column: profiles.Content[j].Column},
+					Rule:    RULE_INVALID_TYPE,
+					Path:    "profiles." + name,
+					Message: "Profile must be a map with properties",
+				}
+			}
+
+			// Profiles can't be changed by profile
+			return withoutKey(profile, "profiles"), nil
+		}
+
+		return nil, &Error{
+			Pos:     Position{File: files[key], Line: key.Line, Column: key.Column},
+			Rule:    RULE_INVALID_VALUE,
+			Path:    "profiles",
+			Message: fmt.Sprintf("Profile %q is not defined", name),
+		}
+	}
+
+	return nil, &Error{Rule: RULE_INVALID_VALUE, Message: fmt.Sprintf("Profile %q is not defined", name)}
+}
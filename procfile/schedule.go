@@ -0,0 +1,255 @@
+package procfile
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                           Copyright (c) 2006-2024 FUNBOX                           //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/essentialkaos/ek/v13/errors"
+	"github.com/essentialkaos/go-simpleyaml/v2"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+const (
+	REGEXP_INTERVAL_CHECK = `\A[1-9][0-9]*[smhd]?\z`
+	REGEXP_CALENDAR_CHECK = `\A[A-Za-z0-9*:.,/~ \-]+\z`
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Schedule contains info about scheduled job
+type Schedule struct {
+	Name        string          // Name of job
+	Cmd         string          // Command
+	CmdArgs     []string        // Command as list of arguments
+	Calendar    string          // Calendar spec in systemd OnCalendar format
+	Interval    string          // Interval between runs (i.e. 30s, 15m, 2h, 1d)
+	Options     *ServiceOptions // Working dir, user, log and environment
+	HelperPath  string          // Path to helper
+	Application *Application    // Pointer to parent application
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Known properties of scheduled job
+var (
+	v2ScheduleProps = []string{"command", "calendar", "interval"}
+
+	// v2JobOptionsProps contains options supported by scheduled jobs and tasks
+	v2JobOptionsProps = []string{
+		"working_directory", "user", "group", "log", "env", "env_file",
+	}
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// AsService returns service with command and options of job, which can be used
+// for rendering helpers and environment
+func (s *Schedule) AsService() *Service {
+	return &Service{
+		Name:        s.Name,
+		Cmd:         s.Cmd,
+		CmdArgs:     s.CmdArgs,
+		Options:     s.Options,
+		HelperPath:  s.HelperPath,
+		Application: s.Application,
+	}
+}
+
+// GetUser returns name of user for running job
+func (s *Schedule) GetUser() string {
+	return s.AsService().GetUser()
+}
+
+// GetGroup returns name of group for running job
+func (s *Schedule) GetGroup() string {
+	return s.AsService().GetGroup()
+}
+
+// GetIntervalSeconds returns interval between runs in seconds
+func (s *Schedule) GetIntervalSeconds() int {
+	if s.Interval == "" {
+		return 0
+	}
+
+	var value int
+	var unit string
+
+	fmt.Sscanf(s.Interval, "%d%s", &value, &unit)
+
+	switch unit {
+	case "m":
+		return value * 60
+	case "h":
+		return value * 3600
+	case "d":
+		return value * 86400
+	}
+
+	return value
+}
+
+// Validate validates scheduled job
+func (s *Schedule) Validate() *errors.Bundle {
+	var errs errors.Bundle
+
+	if !regexp.MustCompile(REGEXP_NAME_CHECK).MatchString(s.Name) {
+		errs.Add(&Error{Rule: RULE_INVALID_NAME, Message: fmt.Sprintf("Job name %s is misformatted and can't be accepted", s.Name)})
+	}
+
+	if s.Application != nil && s.Application.GetServiceByUnit(s.Name) != nil {
+		errs.Add(&Error{Rule: RULE_INVALID_NAME, Message: fmt.Sprintf("Job name %s is already used by command", s.Name)})
+	}
+
+	if s.Cmd == "" {
+		errs.Add(&Error{Rule: RULE_INVALID_VALUE, Path: "command", Message: "job command can't be empty"})
+	}
+
+	if (s.Calendar == "") == (s.Interval == "") {
+		errs.Add(&Error{Rule: RULE_INVALID_VALUE, Message: "job must contain only one of calendar or interval"})
+	}
+
+	if s.Calendar != "" && !regexp.MustCompile(REGEXP_CALENDAR_CHECK).MatchString(s.Calendar) {
+		errs.Add(&Error{Rule: RULE_INVALID_VALUE, Path: "calendar", Message: fmt.Sprintf("Calendar spec %q is misformatted and can't be accepted", s.Calendar)})
+	}
+
+	if s.Interval != "" && !regexp.MustCompile(REGEXP_INTERVAL_CHECK).MatchString(s.Interval) {
+		errs.Add(&Error{Rule: RULE_INVALID_VALUE, Path: "interval", Message: "must contain number with optional unit (s, m, h or d)"})
+	}
+
+	errs.Add(s.Options.Validate())
+
+	s.annotate(errs.All()...)
+
+	return &errs
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// annotate adds path of job to errors and finds their position in procfile
+func (s *Schedule) annotate(errs ...error) {
+	for _, err := range errs {
+		e, ok := err.(*Error)
+
+		if !ok || !e.Pos.IsZero() || strings.HasPrefix(e.Path, "schedules.") {
+			continue
+		}
+
+		e.Path = strings.TrimSuffix("schedules."+s.Name+"."+e.Path, ".")
+	}
+
+	if s.Application != nil {
+		s.Application.source.annotate("", errs...)
+	}
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// parseV2Schedules parse scheduled jobs
+func parseV2Schedules(yaml *simpleyaml.Yaml, src *source) ([]*Schedule, error) {
+	if !yaml.IsExist("schedules") {
+		return nil, nil
+	}
+
+	jobs, err := yaml.Get("schedules").GetMapKeys()
+
+	if err != nil {
+		return nil, formatPropError("schedules", err)
+	}
+
+	commonOptions := &ServiceOptions{}
+	err = parseV2Options(commonOptions, yaml, "")
+
+	if err != nil {
+		return nil, err
+	}
+
+	var schedules []*Schedule
+
+	for _, name := range jobs {
+		jobYaml := yaml.GetPath("schedules", name)
+		prefix := "schedules." + name + "."
+
+		if !jobYaml.IsMap() {
+			return nil, &Error{Rule: RULE_INVALID_TYPE, Path: prefix[:len(prefix)-1], Message: "expected map"}
+		}
+
+		schedule := &Schedule{Name: name}
+		schedule.Cmd, schedule.CmdArgs, err = parseV2CommandValue(jobYaml, "command", prefix)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if jobYaml.IsExist("calendar") {
+			schedule.Calendar = yamlGetSafe(jobYaml, "calendar")
+		}
+
+		if jobYaml.IsExist("interval") {
+			schedule.Interval = yamlGetSafe(jobYaml, "interval")
+		}
+
+		options := &ServiceOptions{}
+		err = parseV2Options(options, jobYaml, prefix)
+
+		if err != nil {
+			return nil, err
+		}
+
+		schedule.Options = newJobOptions(options, commonOptions)
+		schedules = append(schedules, schedule)
+	}
+
+	sort.SliceStable(schedules, func(i, j int) bool {
+		return src.orderOf("schedules."+schedules[i].Name) < src.orderOf("schedules."+schedules[j].Name)
+	})
+
+	return schedules, nil
+}
+
+// newJobOptions creates options of job with properties supported by
+// scheduled jobs and tasks merged with common options
+func newJobOptions(options, common *ServiceOptions) *ServiceOptions {
+	result := &ServiceOptions{
+		Env:        options.Env,
+		EnvFiles:   options.EnvFiles,
+		WorkingDir: options.WorkingDir,
+		User:       options.User,
+		Group:      options.Group,
+		LogFile:    options.LogFile,
+	}
+
+	mergeServiceOptions(result, &ServiceOptions{
+		Env:        common.Env,
+		EnvFiles:   common.EnvFiles,
+		WorkingDir: common.WorkingDir,
+		User:       common.User,
+		Group:      common.Group,
+		LogFile:    common.LogFile,
+	})
+
+	return result
+}
+
+// marshalSchedule encodes scheduled job to mapping node
+func marshalSchedule(s *Schedule, options *ServiceOptions, skipWorkingDir bool) *yaml.Node {
+	node := newMapNode()
+
+	addCommand(node, "command", s.Cmd, s.CmdArgs)
+	addStringIfSet(node, "calendar", s.Calendar)
+	addStringIfSet(node, "interval", s.Interval)
+
+	marshalOptions(node, options, skipWorkingDir)
+
+	return node
+}
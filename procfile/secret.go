@@ -0,0 +1,168 @@
+package procfile
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                           Copyright (c) 2006-2024 FUNBOX                           //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/essentialkaos/ek/v13/errors"
+	"github.com/essentialkaos/go-simpleyaml/v2"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// REGEXP_SECRET_CHECK contains regexp for checking secret names
+const REGEXP_SECRET_CHECK = `\A[A-Za-z0-9_.\-]+\z`
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Secret contains info about file with secret passed to service
+type Secret struct {
+	Name      string // Name of secret (name of file in credentials directory)
+	File      string // Path to file with secret on host
+	Encrypted bool   // File contains credential encrypted by systemd-creds
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// v2SecretProps contains known properties of secret
+var v2SecretProps = []string{"file", "encrypted"}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// IsSecretsSet returns true if service has secrets
+func (so *ServiceOptions) IsSecretsSet() bool {
+	return len(so.Secrets) != 0
+}
+
+// HasEncryptedSecrets returns true if service has encrypted secrets
+func (so *ServiceOptions) HasEncryptedSecrets() bool {
+	return slices.ContainsFunc(so.Secrets, func(s *Secret) bool { return s.Encrypted })
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Validate validates secret
+func (s *Secret) Validate() *errors.Bundle {
+	var errs errors.Bundle
+
+	path := "secrets." + s.Name
+
+	if !regexp.MustCompile(REGEXP_SECRET_CHECK).MatchString(s.Name) {
+		errs.Add(&Error{Rule: RULE_INVALID_NAME, Path: path, Message: fmt.Sprintf("Secret name %s is misformatted and can't be accepted", s.Name)})
+	}
+
+	// Secrets are usually stored in system directories (i.e. /etc), so
+	// checkPath can't be used here
+	switch {
+	case s.File == "":
+		errs.Add(&Error{Rule: RULE_INVALID_VALUE, Path: path, Message: "path to secret file can't be empty"})
+	case !regexp.MustCompile(REGEXP_PATH_CHECK).MatchString(s.File):
+		errs.Add(&Error{Rule: RULE_INSECURE_PATH, Path: path, Message: fmt.Sprintf("Path %s is insecure and can't be accepted", s.File)})
+	case !strings.HasPrefix(s.File, "/") || strings.Contains(s.File, ".."):
+		errs.Add(&Error{Rule: RULE_INSECURE_PATH, Path: path, Message: "path to secret file must be absolute"})
+	}
+
+	return &errs
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// parseV2Secrets parse secrets defined as path to file or map with path and
+// encrypted flag
+func parseV2Secrets(yaml *simpleyaml.Yaml, prefix string) ([]*Secret, error) {
+	prefix += "secrets"
+
+	if !yaml.IsMap() {
+		return nil, &Error{Rule: RULE_INVALID_TYPE, Path: prefix, Message: "expected map"}
+	}
+
+	names, _ := yaml.GetMapKeys()
+
+	var secrets []*Secret
+
+	for _, name := range names {
+		secretYaml := yaml.Get(name)
+		secret := &Secret{Name: name}
+
+		switch {
+		case secretYaml.IsMap():
+			if secretYaml.IsExist("file") {
+				secret.File = yamlGetSafe(secretYaml, "file")
+			}
+
+			if secretYaml.IsExist("encrypted") {
+				var err error
+
+				secret.Encrypted, err = secretYaml.Get("encrypted").Bool()
+
+				if err != nil {
+					return nil, formatPropError(prefix+"."+name+".encrypted", err)
+				}
+			}
+		case secretYaml.IsArray():
+			return nil, &Error{Rule: RULE_INVALID_TYPE, Path: prefix + "." + name, Message: "expected string or map"}
+		default:
+			secret.File = yamlGetSafe(yaml, name)
+		}
+
+		secrets = append(secrets, secret)
+	}
+
+	sortSecrets(secrets)
+
+	return secrets, nil
+}
+
+// marshalSecrets encodes secrets to mapping node
+func marshalSecrets(secrets []*Secret) *yaml.Node {
+	node := newMapNode()
+
+	for _, secret := range secrets {
+		if !secret.Encrypted {
+			addScalar(node, secret.Name, "!!str", secret.File)
+			continue
+		}
+
+		secretNode := newMapNode()
+
+		addScalar(secretNode, "file", "!!str", secret.File)
+		addScalar(secretNode, "encrypted", "!!bool", "true")
+
+		addNode(node, secret.Name, secretNode)
+	}
+
+	return node
+}
+
+// mergeSecrets returns secrets with common secrets which are not overridden
+// by service
+func mergeSecrets(dst, src []*Secret) []*Secret {
+	result := slices.Clone(dst)
+
+	for _, secret := range src {
+		if !slices.ContainsFunc(dst, func(s *Secret) bool { return s.Name == secret.Name }) {
+			result = append(result, secret)
+		}
+	}
+
+	sortSecrets(result)
+
+	return result
+}
+
+// sortSecrets sorts secrets by name
+func sortSecrets(secrets []*Secret) {
+	slices.SortFunc(secrets, func(a, b *Secret) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+}
@@ -0,0 +1,366 @@
+package procfile
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                           Copyright (c) 2006-2024 FUNBOX                           //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/essentialkaos/ek/v13/errors"
+	"github.com/essentialkaos/go-simpleyaml/v2"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Security presets
+const (
+	SECURITY_PRESET_NONE    = "none"
+	SECURITY_PRESET_DEFAULT = "default"
+	SECURITY_PRESET_STRICT  = "strict"
+)
+
+const (
+	REGEXP_CAPABILITY_CHECK     = `\A~?CAP_[A-Z_]+\z`
+	REGEXP_SYSCALL_CHECK        = `\A~?@?[a-z0-9_\-]+(:[A-Za-z0-9_]+)?\z`
+	REGEXP_ADDRESS_FAMILY_CHECK = `\A(~?AF_[A-Z0-9_]+|none)\z`
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Security contains sandboxing and hardening options (systemd only)
+type Security struct {
+	Preset                  string   // Name of preset with default values
+	NoNewPrivileges         string   // Deny gaining new privileges (yes/no)
+	ProtectSystem           string   // Read-only system dirs (yes/no/full/strict)
+	ProtectHome             string   // Protect home dirs (yes/no/read-only/tmpfs)
+	PrivateTmp              string   // Private /tmp and /var/tmp (yes/no)
+	PrivateDevices          string   // Private /dev (yes/no)
+	ReadWritePaths          []string // Paths writable with read-only system
+	ReadOnlyPaths           []string // Read-only paths
+	CapabilityBoundingSet   []string // Allowed capabilities (empty list drops all)
+	AmbientCapabilities     []string // Capabilities granted to non-root user
+	SystemCallFilter        []string // Allowed system calls
+	RestrictAddressFamilies []string // Allowed socket address families
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// v2SecurityProps contains known properties of security options
+var v2SecurityProps = []string{
+	"preset", "no_new_privileges", "protect_system", "protect_home", "private_tmp",
+	"private_devices", "read_write_paths", "read_only_paths", "capability_bounding_set",
+	"ambient_capabilities", "system_call_filter", "restrict_address_families",
+}
+
+// securityPresets contains values of options for every preset
+var securityPresets = map[string]*Security{
+	SECURITY_PRESET_NONE: {},
+	SECURITY_PRESET_DEFAULT: {
+		NoNewPrivileges: "yes",
+		ProtectSystem:   "full",
+		PrivateTmp:      "yes",
+		PrivateDevices:  "yes",
+	},
+	SECURITY_PRESET_STRICT: {
+		NoNewPrivileges:         "yes",
+		ProtectSystem:           "strict",
+		ProtectHome:             "yes",
+		PrivateTmp:              "yes",
+		PrivateDevices:          "yes",
+		CapabilityBoundingSet:   []string{},
+		SystemCallFilter:        []string{"@system-service"},
+		RestrictAddressFamilies: []string{"AF_UNIX", "AF_INET", "AF_INET6"},
+	},
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// IsSecuritySet returns true if sandboxing options are set
+func (so *ServiceOptions) IsSecuritySet() bool {
+	return so.Security != nil && !so.Security.Resolve().IsEmpty()
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Resolve returns options with values from preset, values defined explicitly
+// override preset values
+func (se *Security) Resolve() *Security {
+	preset, ok := securityPresets[se.Preset]
+
+	if !ok {
+		preset = securityPresets[SECURITY_PRESET_NONE]
+	}
+
+	result := &Security{
+		NoNewPrivileges:         firstNonEmpty(se.NoNewPrivileges, preset.NoNewPrivileges),
+		ProtectSystem:           firstNonEmpty(se.ProtectSystem, preset.ProtectSystem),
+		ProtectHome:             firstNonEmpty(se.ProtectHome, preset.ProtectHome),
+		PrivateTmp:              firstNonEmpty(se.PrivateTmp, preset.PrivateTmp),
+		PrivateDevices:          firstNonEmpty(se.PrivateDevices, preset.PrivateDevices),
+		ReadWritePaths:          se.ReadWritePaths,
+		ReadOnlyPaths:           se.ReadOnlyPaths,
+		CapabilityBoundingSet:   se.CapabilityBoundingSet,
+		AmbientCapabilities:     se.AmbientCapabilities,
+		SystemCallFilter:        se.SystemCallFilter,
+		RestrictAddressFamilies: se.RestrictAddressFamilies,
+	}
+
+	if result.CapabilityBoundingSet == nil {
+		result.CapabilityBoundingSet = preset.CapabilityBoundingSet
+	}
+
+	if result.SystemCallFilter == nil {
+		result.SystemCallFilter = preset.SystemCallFilter
+	}
+
+	if result.RestrictAddressFamilies == nil {
+		result.RestrictAddressFamilies = preset.RestrictAddressFamilies
+	}
+
+	return result
+}
+
+// IsEmpty returns true if no options are set
+func (se *Security) IsEmpty() bool {
+	return se.NoNewPrivileges == "" && se.ProtectSystem == "" && se.ProtectHome == "" &&
+		se.PrivateTmp == "" && se.PrivateDevices == "" && se.ReadWritePaths == nil &&
+		se.ReadOnlyPaths == nil && se.CapabilityBoundingSet == nil &&
+		se.AmbientCapabilities == nil && se.SystemCallFilter == nil &&
+		se.RestrictAddressFamilies == nil
+}
+
+// Validate validates security options
+func (se *Security) Validate() *errors.Bundle {
+	var errs errors.Bundle
+
+	if se.Preset != "" && securityPresets[se.Preset] == nil {
+		errs.Add(&Error{Rule: RULE_INVALID_VALUE, Path: "security.preset", Message: "must contain 'none', 'default' or 'strict'"})
+	}
+
+	for prop, value := range map[string]string{
+		"no_new_privileges": se.NoNewPrivileges,
+		"private_tmp":       se.PrivateTmp,
+		"private_devices":   se.PrivateDevices,
+	} {
+		if value != "" && value != "yes" && value != "no" {
+			errs.Add(&Error{Rule: RULE_INVALID_VALUE, Path: "security." + prop, Message: "must contain boolean value"})
+		}
+	}
+
+	if se.ProtectSystem != "" && !slices.Contains([]string{"yes", "no", "full", "strict"}, se.ProtectSystem) {
+		errs.Add(&Error{Rule: RULE_INVALID_VALUE, Path: "security.protect_system", Message: "must contain boolean value, 'full' or 'strict'"})
+	}
+
+	if se.ProtectHome != "" && !slices.Contains([]string{"yes", "no", "read-only", "tmpfs"}, se.ProtectHome) {
+		errs.Add(&Error{Rule: RULE_INVALID_VALUE, Path: "security.protect_home", Message: "must contain boolean value, 'read-only' or 'tmpfs'"})
+	}
+
+	for _, p := range se.ReadWritePaths {
+		errs.Add(newError(RULE_INSECURE_PATH, "security.read_write_paths", checkPath(p)))
+	}
+
+	for _, p := range se.ReadOnlyPaths {
+		errs.Add(newError(RULE_INSECURE_PATH, "security.read_only_paths", checkPath(p)))
+	}
+
+	errs.Add(checkSecurityValues("capability_bounding_set", "capability", REGEXP_CAPABILITY_CHECK, se.CapabilityBoundingSet))
+	errs.Add(checkSecurityValues("ambient_capabilities", "capability", REGEXP_CAPABILITY_CHECK, se.AmbientCapabilities))
+	errs.Add(checkSecurityValues("system_call_filter", "system call", REGEXP_SYSCALL_CHECK, se.SystemCallFilter))
+	errs.Add(checkSecurityValues("restrict_address_families", "address family", REGEXP_ADDRESS_FAMILY_CHECK, se.RestrictAddressFamilies))
+
+	return &errs
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// parseV2Security parse sandboxing options
+func parseV2Security(yaml *simpleyaml.Yaml, prefix string) (*Security, error) {
+	var err error
+
+	if !yaml.IsMap() {
+		return nil, &Error{Rule: RULE_INVALID_TYPE, Path: prefix[:len(prefix)-1], Message: "expected map"}
+	}
+
+	security := &Security{}
+
+	if yaml.IsExist("preset") {
+		security.Preset = yamlGetSafe(yaml, "preset")
+	}
+
+	security.NoNewPrivileges = parseV2SecurityFlag(yaml, "no_new_privileges")
+	security.ProtectSystem = parseV2SecurityFlag(yaml, "protect_system")
+	security.ProtectHome = parseV2SecurityFlag(yaml, "protect_home")
+	security.PrivateTmp = parseV2SecurityFlag(yaml, "private_tmp")
+	security.PrivateDevices = parseV2SecurityFlag(yaml, "private_devices")
+
+	for prop, list := range map[string]*[]string{
+		"read_write_paths":          &security.ReadWritePaths,
+		"read_only_paths":           &security.ReadOnlyPaths,
+		"capability_bounding_set":   &security.CapabilityBoundingSet,
+		"ambient_capabilities":      &security.AmbientCapabilities,
+		"system_call_filter":        &security.SystemCallFilter,
+		"restrict_address_families": &security.RestrictAddressFamilies,
+	} {
+		*list, err = parseV2SecurityList(yaml, prop, prefix)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return security, nil
+}
+
+// parseV2SecurityFlag parse boolean option, which also can contain one of
+// additional values (i.e. 'strict' for protect_system)
+func parseV2SecurityFlag(yaml *simpleyaml.Yaml, prop string) string {
+	if !yaml.IsExist(prop) {
+		return ""
+	}
+
+	switch value := yamlGetSafe(yaml, prop); value {
+	case "true":
+		return "yes"
+	case "false":
+		return "no"
+	default:
+		return value
+	}
+}
+
+// parseV2SecurityList parse list of values defined as list or string with
+// values separated by spaces, empty list is kept as empty slice
+func parseV2SecurityList(yaml *simpleyaml.Yaml, prop, prefix string) ([]string, error) {
+	if !yaml.IsExist(prop) {
+		return nil, nil
+	}
+
+	if !yaml.Get(prop).IsArray() {
+		return strings.Fields(yamlGetSafe(yaml, prop)), nil
+	}
+
+	items, _ := yaml.Get(prop).Array()
+	result := make([]string, len(items))
+
+	for index, item := range items {
+		switch item.(type) {
+		case nil, []interface{}, map[interface{}]interface{}:
+			return nil, &Error{Rule: RULE_INVALID_TYPE, Path: prefix + prop, Message: "values must be strings"}
+		}
+
+		result[index] = fmt.Sprint(item)
+	}
+
+	return result, nil
+}
+
+// marshalSecurity encodes security options to mapping node
+func marshalSecurity(se *Security) *yaml.Node {
+	node := newMapNode()
+
+	addStringIfSet(node, "preset", se.Preset)
+	addSecurityFlag(node, "no_new_privileges", se.NoNewPrivileges)
+	addSecurityFlag(node, "protect_system", se.ProtectSystem)
+	addSecurityFlag(node, "protect_home", se.ProtectHome)
+	addSecurityFlag(node, "private_tmp", se.PrivateTmp)
+	addSecurityFlag(node, "private_devices", se.PrivateDevices)
+
+	for _, item := range []struct {
+		key    string
+		values []string
+	}{
+		{"read_write_paths", se.ReadWritePaths},
+		{"read_only_paths", se.ReadOnlyPaths},
+		{"capability_bounding_set", se.CapabilityBoundingSet},
+		{"ambient_capabilities", se.AmbientCapabilities},
+		{"system_call_filter", se.SystemCallFilter},
+		{"restrict_address_families", se.RestrictAddressFamilies},
+	} {
+		if item.values != nil {
+			addList(node, item.key, item.values)
+		}
+	}
+
+	return node
+}
+
+// addSecurityFlag adds boolean option to mapping node
+func addSecurityFlag(node *yaml.Node, key, value string) {
+	switch value {
+	case "":
+		return
+	case "yes":
+		addScalar(node, key, "!!bool", "true")
+	case "no":
+		addScalar(node, key, "!!bool", "false")
+	default:
+		addScalar(node, key, "!!str", value)
+	}
+}
+
+// mergeSecurity returns options with unset values taken from common options
+func mergeSecurity(dst, src *Security) *Security {
+	if dst == nil {
+		return src
+	}
+
+	result := *dst
+
+	result.Preset = firstNonEmpty(dst.Preset, src.Preset)
+	result.NoNewPrivileges = firstNonEmpty(dst.NoNewPrivileges, src.NoNewPrivileges)
+	result.ProtectSystem = firstNonEmpty(dst.ProtectSystem, src.ProtectSystem)
+	result.ProtectHome = firstNonEmpty(dst.ProtectHome, src.ProtectHome)
+	result.PrivateTmp = firstNonEmpty(dst.PrivateTmp, src.PrivateTmp)
+	result.PrivateDevices = firstNonEmpty(dst.PrivateDevices, src.PrivateDevices)
+
+	for _, item := range []struct{ dst, src *[]string }{
+		{&result.ReadWritePaths, &src.ReadWritePaths},
+		{&result.ReadOnlyPaths, &src.ReadOnlyPaths},
+		{&result.CapabilityBoundingSet, &src.CapabilityBoundingSet},
+		{&result.AmbientCapabilities, &src.AmbientCapabilities},
+		{&result.SystemCallFilter, &src.SystemCallFilter},
+		{&result.RestrictAddressFamilies, &src.RestrictAddressFamilies},
+	} {
+		if *item.dst == nil {
+			*item.dst = *item.src
+		}
+	}
+
+	return &result
+}
+
+// checkSecurityValues checks format of every value in list
+func checkSecurityValues(prop, kind, pattern string, values []string) *errors.Bundle {
+	var errs errors.Bundle
+
+	for _, value := range values {
+		if !regexp.MustCompile(pattern).MatchString(value) {
+			errs.Add(&Error{
+				Rule:    RULE_INVALID_VALUE,
+				Path:    "security." + prop,
+				Message: fmt.Sprintf("Name of %s %s is misformatted and can't be accepted", kind, value),
+			})
+		}
+	}
+
+	return &errs
+}
+
+// firstNonEmpty returns first non-empty string
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
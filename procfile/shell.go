@@ -0,0 +1,351 @@
+package procfile
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                           Copyright (c) 2006-2024 FUNBOX                           //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Kinds of shell tokens
+const (
+	TOKEN_WORD     TokenKind = iota // Word (command, argument or assignment)
+	TOKEN_OPERATOR                  // Control operator (&&, ||, ;, |, &, ( and ))
+	TOKEN_REDIRECT                  // Redirection operator with optional descriptor (>>, 2>&)
+)
+
+// REGEXP_SAFE_ARG is regexp for arguments which don't require quoting
+const REGEXP_SAFE_ARG = `^[A-Za-z0-9_@%+=:,./-]+$`
+
+// REGEXP_ASSIGNMENT is regexp for checking variable assignment (name is checked
+// by validation, so assignments with misformatted names are also matched)
+const REGEXP_ASSIGNMENT = "^[^=\\s'\"$`\\\\]+="
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// TokenKind is kind of shell token
+type TokenKind uint8
+
+// Token is shell command token
+type Token struct {
+	Kind  TokenKind // Kind of token
+	Value string    // Value of token without quotes and escaping
+	Raw   string    // Original text of token
+	Pos   int       // Offset of first byte of token in command
+	End   int       // Offset of byte after the token in command
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// shellOperators contains all supported operators (longest first)
+var shellOperators = []string{
+	"&>>", "&&", "||", "&>", ">>", ">&", ">|", "<&", "<>", "<<",
+	">", "<", "|", "&", ";", "(", ")",
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Tokenize splits shell command into tokens using POSIX shell rules for quoting,
+// escaping, operators and redirections. Command and parameter substitutions are
+// kept as is.
+func Tokenize(command string) ([]Token, error) {
+	var result []Token
+
+	for i := 0; i < len(command); {
+		switch {
+		case isShellSpace(command[i]):
+			i++
+
+		case command[i] == '#':
+			// Comment lasts until the end of the command
+			i = len(command)
+
+		case getShellOperator(command, i) != "":
+			op := getShellOperator(command, i)
+			result = append(result, newOperatorToken(command, op, i, i+len(op)))
+			i += len(op)
+
+		default:
+			token, err := readShellWord(command, i)
+
+			if err != nil {
+				return nil, err
+			}
+
+			result = append(result, token)
+			i = token.End
+		}
+	}
+
+	for index, token := range result {
+		if token.Kind != TOKEN_REDIRECT {
+			continue
+		}
+
+		if index+1 == len(result) || result[index+1].Kind != TOKEN_WORD {
+			return nil, fmt.Errorf("Redirection %q has no target", token.Raw)
+		}
+	}
+
+	return result, nil
+}
+
+// QuoteArgs joins arguments to shell command, arguments with special characters
+// are quoted
+func QuoteArgs(args []string) string {
+	result := make([]string, len(args))
+
+	for index, arg := range args {
+		result[index] = QuoteArg(arg)
+	}
+
+	return strings.Join(result, " ")
+}
+
+// QuoteArg quotes argument for shell if required
+func QuoteArg(arg string) string {
+	if regexp.MustCompile(REGEXP_SAFE_ARG).MatchString(arg) {
+		return arg
+	}
+
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// IsWord returns true if token is word with given value
+func (t Token) IsWord(value string) bool {
+	return t.Kind == TOKEN_WORD && t.Value == value
+}
+
+// IsOperator returns true if token is operator with given value
+func (t Token) IsOperator(value string) bool {
+	return t.Kind == TOKEN_OPERATOR && t.Value == value
+}
+
+// IsAssignment returns true if token is variable assignment (NAME=value)
+func (t Token) IsAssignment() bool {
+	return t.Kind == TOKEN_WORD && regexp.MustCompile(REGEXP_ASSIGNMENT).MatchString(t.Raw)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// readShellWord reads word (or redirection with descriptor number) which
+// starts at given offset
+func readShellWord(command string, start int) (Token, error) {
+	var value strings.Builder
+
+	i := start
+
+	for i < len(command) {
+		c := command[i]
+
+		if isShellSpace(c) {
+			break
+		}
+
+		if getShellOperator(command, i) != "" {
+			// Descriptor number before redirection (i.e. 2>&1)
+			if (c == '>' || c == '<') && i > start && isDigits(command[start:i]) {
+				op := getShellOperator(command, i)
+				return newOperatorToken(command, command[start:i]+op, start, i+len(op)), nil
+			}
+
+			break
+		}
+
+		switch c {
+		case '\\':
+			if i+1 < len(command) {
+				value.WriteByte(command[i+1])
+				i += 2
+			} else {
+				value.WriteByte(c)
+				i++
+			}
+
+		case '\'':
+			end := strings.IndexByte(command[i+1:], '\'')
+
+			if end == -1 {
+				return Token{}, fmt.Errorf("Unterminated single quote at %d", i+1)
+			}
+
+			value.WriteString(command[i+1 : i+1+end])
+			i += end + 2
+
+		case '"':
+			end, err := readDoubleQuoted(command, i, &value)
+
+			if err != nil {
+				return Token{}, err
+			}
+
+			i = end
+
+		case '$', '`':
+			end, err := readSubstitution(command, i)
+
+			if err != nil {
+				return Token{}, err
+			}
+
+			value.WriteString(command[i:end])
+			i = end
+
+		default:
+			value.WriteByte(c)
+			i++
+		}
+	}
+
+	return Token{
+		Kind:  TOKEN_WORD,
+		Value: value.String(),
+		Raw:   command[start:i],
+		Pos:   start,
+		End:   i,
+	}, nil
+}
+
+// readDoubleQuoted reads double-quoted string which starts at given offset
+// and returns offset of byte after closing quote
+func readDoubleQuoted(command string, start int, value *strings.Builder) (int, error) {
+	for i := start + 1; i < len(command); {
+		c := command[i]
+
+		switch c {
+		case '"':
+			return i + 1, nil
+
+		case '\\':
+			if i+1 < len(command) && strings.IndexByte("$`\"\\\n", command[i+1]) != -1 {
+				value.WriteByte(command[i+1])
+				i += 2
+			} else {
+				value.WriteByte(c)
+				i++
+			}
+
+		case '$', '`':
+			end, err := readSubstitution(command, i)
+
+			if err != nil {
+				return 0, err
+			}
+
+			value.WriteString(command[i:end])
+			i = end
+
+		default:
+			value.WriteByte(c)
+			i++
+		}
+	}
+
+	return 0, fmt.Errorf("Unterminated double quote at %d", start+1)
+}
+
+// readSubstitution reads parameter, command or arithmetic substitution
+// which starts at given offset and returns offset of byte after it
+func readSubstitution(command string, start int) (int, error) {
+	if command[start] == '`' {
+		for i := start + 1; i < len(command); i++ {
+			switch command[i] {
+			case '\\':
+				i++
+			case '`':
+				return i + 1, nil
+			}
+		}
+
+		return 0, fmt.Errorf("Unterminated command substitution at %d", start+1)
+	}
+
+	if start+1 >= len(command) {
+		return start + 1, nil
+	}
+
+	var open, close byte
+
+	switch command[start+1] {
+	case '(':
+		open, close = '(', ')'
+	case '{':
+		open, close = '{', '}'
+	default:
+		return start + 1, nil
+	}
+
+	depth := 0
+
+	for i := start + 1; i < len(command); i++ {
+		switch command[i] {
+		case '\\':
+			i++
+		case '\'':
+			end := strings.IndexByte(command[i+1:], '\'')
+
+			if end == -1 {
+				return 0, fmt.Errorf("Unterminated single quote at %d", i+1)
+			}
+
+			i += end + 1
+		case open:
+			depth++
+		case close:
+			depth--
+
+			if depth == 0 {
+				return i + 1, nil
+			}
+		}
+	}
+
+	return 0, fmt.Errorf("Unterminated substitution at %d", start+1)
+}
+
+// newOperatorToken creates operator or redirection token
+func newOperatorToken(command, op string, start, end int) Token {
+	kind := TOKEN_OPERATOR
+
+	if strings.ContainsAny(op, "<>") {
+		kind = TOKEN_REDIRECT
+	}
+
+	return Token{Kind: kind, Value: op, Raw: command[start:end], Pos: start, End: end}
+}
+
+// getShellOperator returns operator which starts at given offset
+func getShellOperator(command string, offset int) string {
+	for _, op := range shellOperators {
+		if strings.HasPrefix(command[offset:], op) {
+			return op
+		}
+	}
+
+	return ""
+}
+
+// isShellSpace returns true if given byte is a blank character
+func isShellSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// isDigits returns true if string contains only digits
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return s != ""
+}
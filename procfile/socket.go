@@ -0,0 +1,205 @@
+package procfile
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                           Copyright (c) 2006-2024 FUNBOX                           //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/essentialkaos/ek/v13/errors"
+	"github.com/essentialkaos/go-simpleyaml/v2"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// REGEXP_SOCKET_CHECK contains regexp for checking socket addresses
+const REGEXP_SOCKET_CHECK = `\A[A-Za-z0-9_.:/@%\[\]\-]+\z`
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Sockets contains info about sockets for socket activation (systemd only)
+type Sockets struct {
+	Stream   []string // Addresses of stream sockets
+	Datagram []string // Addresses of datagram sockets
+	Backlog  int      // Max length of queue of pending connections
+	Accept   bool     // Start service instance for every connection
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// v2SocketsProps contains known properties of sockets
+var v2SocketsProps = []string{"stream", "datagram", "backlog", "accept"}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// HasSockets returns true if service is started by socket activation
+func (s *Service) HasSockets() bool {
+	return s.Sockets != nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Validate validates sockets properties
+func (so *Sockets) Validate() *errors.Bundle {
+	var errs errors.Bundle
+
+	if len(so.Stream) == 0 && len(so.Datagram) == 0 {
+		errs.Add(&Error{
+			Rule:    RULE_INVALID_VALUE,
+			Path:    "sockets",
+			Message: "sockets must contain at least one stream or datagram address",
+		})
+	}
+
+	for _, address := range so.Stream {
+		errs.Add(newError(RULE_INVALID_VALUE, "sockets.stream", checkSocketAddress(address)))
+	}
+
+	for _, address := range so.Datagram {
+		errs.Add(newError(RULE_INVALID_VALUE, "sockets.datagram", checkSocketAddress(address)))
+	}
+
+	if so.Backlog < 0 {
+		errs.Add(&Error{Rule: RULE_INVALID_VALUE, Path: "sockets.backlog", Message: "must be greater or equal 0"})
+	}
+
+	return &errs
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// parseV2Sockets parse service sockets
+func parseV2Sockets(yaml *simpleyaml.Yaml, prefix string) (*Sockets, error) {
+	var err error
+
+	prefix += "sockets"
+
+	if !yaml.IsMap() {
+		return nil, &Error{Rule: RULE_INVALID_TYPE, Path: prefix, Message: "expected map"}
+	}
+
+	sockets := &Sockets{}
+
+	sockets.Stream, err = parseV2SocketAddresses(yaml, "stream", prefix)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sockets.Datagram, err = parseV2SocketAddresses(yaml, "datagram", prefix)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if yaml.IsExist("backlog") {
+		sockets.Backlog, err = yaml.Get("backlog").Int()
+
+		if err != nil {
+			return nil, formatPropError(prefix+".backlog", err)
+		}
+	}
+
+	if yaml.IsExist("accept") {
+		sockets.Accept, err = yaml.Get("accept").Bool()
+
+		if err != nil {
+			return nil, formatPropError(prefix+".accept", err)
+		}
+	}
+
+	return sockets, nil
+}
+
+// parseV2SocketAddresses parse socket address or list of addresses
+func parseV2SocketAddresses(yaml *simpleyaml.Yaml, prop, prefix string) ([]string, error) {
+	if !yaml.IsExist(prop) {
+		return nil, nil
+	}
+
+	if !yaml.Get(prop).IsArray() {
+		return []string{yamlGetSafe(yaml, prop)}, nil
+	}
+
+	items, _ := yaml.Get(prop).Array()
+	result := make([]string, len(items))
+
+	for index, item := range items {
+		switch item.(type) {
+		case nil, []interface{}, map[interface{}]interface{}:
+			return nil, &Error{Rule: RULE_INVALID_TYPE, Path: prefix + "." + prop, Message: "addresses must be strings"}
+		}
+
+		result[index] = fmt.Sprint(item)
+	}
+
+	return result, nil
+}
+
+// marshalSockets encodes sockets to mapping node
+func marshalSockets(so *Sockets) *yaml.Node {
+	node := newMapNode()
+
+	addSocketAddresses(node, "stream", so.Stream)
+	addSocketAddresses(node, "datagram", so.Datagram)
+	addIntIfSet(node, "backlog", so.Backlog)
+
+	if so.Accept {
+		addScalar(node, "accept", "!!bool", "true")
+	}
+
+	return node
+}
+
+// addSocketAddresses adds socket address or list of addresses to mapping node
+func addSocketAddresses(node *yaml.Node, key string, addresses []string) {
+	switch len(addresses) {
+	case 0:
+		return
+	case 1:
+		addScalar(node, key, "!!str", addresses[0])
+	default:
+		addList(node, key, addresses)
+	}
+}
+
+// checkSocketAddress checks socket address (port, host:port or path to unix
+// socket) and return error if address is invalid
+func checkSocketAddress(address string) error {
+	if !regexp.MustCompile(REGEXP_SOCKET_CHECK).MatchString(address) {
+		return fmt.Errorf("Socket address %s is misformatted and can't be accepted", address)
+	}
+
+	if strings.HasPrefix(address, "/") || strings.HasPrefix(address, "@") {
+		return nil
+	}
+
+	port := address
+
+	if strings.Contains(address, ":") {
+		var err error
+
+		_, port, err = net.SplitHostPort(address)
+
+		if err != nil {
+			return fmt.Errorf("Socket address %s is misformatted and can't be accepted", address)
+		}
+	}
+
+	portNum, err := strconv.Atoi(port)
+
+	if err != nil || portNum < 1 || portNum > MAX_PORT {
+		return fmt.Errorf("Socket address %s must contain port in range 1-%d", address, MAX_PORT)
+	}
+
+	return nil
+}
@@ -0,0 +1,354 @@
+package procfile
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                           Copyright (c) 2006-2024 FUNBOX                           //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+const (
+	// REGEXP_YAML_ERROR is regexp for extracting line number from YAML syntax errors
+	REGEXP_YAML_ERROR = `^yaml: line (\d+): (.+)$`
+
+	// REGEXP_IGNORE_COMMENT is regexp for inline comments with ignored lint rules
+	REGEXP_IGNORE_COMMENT = `#\s*init-exporter:ignore(\s.*)?$`
+)
+
+// Rules IDs for procfile errors
+const (
+	RULE_SYNTAX           = "syntax"
+	RULE_INVALID_TYPE     = "invalid-type"
+	RULE_INVALID_VALUE    = "invalid-value"
+	RULE_INVALID_NAME     = "invalid-name"
+	RULE_INVALID_ENV      = "invalid-env"
+	RULE_INSECURE_PATH    = "insecure-path"
+	RULE_UNKNOWN_PROPERTY = "unknown-property"
+	RULE_UNSUPPORTED      = "unsupported"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Position contains position of property in procfile
+type Position struct {
+	File   string // Path to procfile
+	Line   int    // Line number (starting from 1)
+	Column int    // Column number (starting from 1)
+}
+
+// Error is procfile parsing or validation error with information about its source
+type Error struct {
+	Pos     Position // Position of property in procfile
+	Rule    string   // Rule ID
+	Service string   // Service name
+	Path    string   // Property path (i.e. commands.web.limits.nofile)
+	Message string   // Error message
+}
+
+// source contains positions of properties in procfile
+type source struct {
+	file      string
+	positions map[string]Position
+	order     map[string]int // Order of properties in document
+	ignores   map[int][]string
+	files     map[*yaml.Node]string // Files of keys from included files
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Error returns error text in compiler-like format
+func (e *Error) Error() string {
+	return formatProblem(e.Pos, e.Path, e.Message)
+}
+
+// IsZero returns true if position is empty
+func (p Position) IsZero() bool {
+	return p.Line == 0
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// newSource parses YAML data and creates index with positions of all properties
+func newSource(data []byte) (*source, error) {
+	var root yaml.Node
+
+	src := newEmptySource(data)
+	err := yaml.Unmarshal(data, &root)
+
+	if err != nil {
+		return src, parseYAMLError(err)
+	}
+
+	if len(root.Content) != 0 {
+		src.index(root.Content[0], "")
+	}
+
+	return src, nil
+}
+
+// newEmptySource creates source without positions and with ignore
+// comments from given data
+func newEmptySource(data []byte) *source {
+	src := &source{
+		positions: make(map[string]Position),
+		order:     make(map[string]int),
+		ignores:   make(map[int][]string),
+	}
+
+	var pending []int
+
+	ignoreRegexp := regexp.MustCompile(REGEXP_IGNORE_COMMENT)
+
+	for index, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+
+		if line == "" {
+			continue
+		}
+
+		isComment := strings.HasPrefix(line, "#")
+
+		if !isComment {
+			for _, commentLine := range pending {
+				src.ignores[index+1] = append(src.ignores[index+1], src.ignores[commentLine]...)
+				delete(src.ignores, commentLine)
+			}
+
+			pending = nil
+		}
+
+		matches := ignoreRegexp.FindStringSubmatch(line)
+
+		if matches == nil {
+			continue
+		}
+
+		rules := strings.Fields(strings.ReplaceAll(matches[1], ",", " "))
+
+		if len(rules) == 0 {
+			rules = []string{"*"}
+		}
+
+		src.ignores[index+1] = append(src.ignores[index+1], rules...)
+
+		if isComment {
+			pending = append(pending, index+1)
+		}
+	}
+
+	return src
+}
+
+// index adds positions of all keys in given node to index
+func (s *source) index(node *yaml.Node, prefix string) {
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+
+		if key.Tag == "!!merge" {
+			s.index(value, prefix)
+			continue
+		}
+
+		path := prefix + key.Value
+
+		s.positions[path] = Position{File: s.files[key], Line: key.Line, Column: key.Column}
+		s.order[path] = len(s.order)
+		s.index(value, path+".")
+	}
+}
+
+// add adds position of property to index
+func (s *source) add(path string, pos Position) {
+	if s == nil {
+		return
+	}
+
+	s.positions[path] = pos
+}
+
+// orderOf returns index of property in document
+func (s *source) orderOf(path string) int {
+	if s == nil {
+		return 0
+	}
+
+	return s.order[path]
+}
+
+// locate finds the most specific known path and position of given property
+// of service or application (if service name is empty)
+func (s *source) locate(service, prop string) (string, Position) {
+	for path := prop; path != ""; path = parentPath(path) {
+		if service != "" {
+			pos, ok := s.find("commands." + service + "." + path)
+
+			if ok {
+				return "commands." + service + "." + path, pos
+			}
+		}
+
+		pos, ok := s.find(path)
+
+		if ok {
+			return path, pos
+		}
+	}
+
+	if service == "" {
+		pos, _ := s.find(prop)
+		return prop, pos
+	}
+
+	pos, _ := s.find("commands." + service)
+
+	return strings.TrimSuffix("commands."+service+"."+prop, "."), pos
+}
+
+// isIgnored returns true if given rule is ignored by inline comment
+// for property or service
+func (s *source) isIgnored(rule, service string, pos Position) bool {
+	if s == nil {
+		return false
+	}
+
+	var lines []int
+
+	// Ignore comments are supported only in main procfile
+	if pos.File == s.file {
+		lines = append(lines, pos.Line)
+	}
+
+	if service != "" {
+		servicePos, ok := s.find("commands." + service)
+
+		if ok && servicePos.File == s.file {
+			lines = append(lines, servicePos.Line)
+		}
+	}
+
+	for _, line := range lines {
+		rules := s.ignores[line]
+
+		if slices.Contains(rules, rule) || slices.Contains(rules, "*") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// find returns position of property with given path
+func (s *source) find(path string) (Position, bool) {
+	if s == nil {
+		return Position{}, false
+	}
+
+	pos, ok := s.positions[path]
+
+	if !ok {
+		return Position{File: s.file}, false
+	}
+
+	if pos.File == "" {
+		pos.File = s.file
+	}
+
+	return pos, true
+}
+
+// annotate adds information about source to all procfile errors
+func (s *source) annotate(service string, errs ...error) {
+	for _, err := range errs {
+		e, ok := err.(*Error)
+
+		if !ok || !e.Pos.IsZero() {
+			continue
+		}
+
+		if e.Service == "" {
+			e.Service = service
+		}
+
+		e.Path, e.Pos = s.locate(e.Service, e.Path)
+	}
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// newError creates new procfile error for property with given path
+func newError(rule, path string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &Error{Rule: rule, Path: path, Message: err.Error()}
+}
+
+// parentPath returns path of parent property
+func parentPath(path string) string {
+	index := strings.LastIndex(path, ".")
+
+	if index == -1 {
+		return ""
+	}
+
+	return path[:index]
+}
+
+// formatProblem formats problem message in compiler-like format
+func formatProblem(pos Position, path, message string) string {
+	var result string
+
+	if pos.File != "" {
+		result += pos.File + ":"
+	}
+
+	if pos.Line > 0 {
+		result += strconv.Itoa(pos.Line) + ":"
+
+		if pos.Column > 0 {
+			result += strconv.Itoa(pos.Column) + ":"
+		}
+	}
+
+	if result != "" {
+		result += " "
+	}
+
+	if path != "" {
+		result += path + ": "
+	}
+
+	return result + message
+}
+
+// parseYAMLError converts YAML syntax error to procfile error
+func parseYAMLError(err error) error {
+	matches := regexp.MustCompile(REGEXP_YAML_ERROR).FindStringSubmatch(err.Error())
+
+	if len(matches) != 3 {
+		return err
+	}
+
+	line, _ := strconv.Atoi(matches[1])
+
+	return &Error{Pos: Position{Line: line}, Rule: RULE_SYNTAX, Message: matches[2]}
+}
@@ -0,0 +1,176 @@
+package procfile
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                           Copyright (c) 2006-2024 FUNBOX                           //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/essentialkaos/ek/v13/errors"
+	"github.com/essentialkaos/go-simpleyaml/v2"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Task contains info about one-shot task executed before services start
+// (i.e. database migrations)
+type Task struct {
+	Name        string          // Name of task
+	Cmd         string          // Command
+	CmdArgs     []string        // Command as list of arguments
+	Options     *ServiceOptions // Working dir, user, log and environment
+	HelperPath  string          // Path to helper
+	Application *Application    // Pointer to parent application
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// v2TaskProps contains known properties of task
+var v2TaskProps = []string{"command"}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// AsService returns service with command and options of task, which can be used
+// for rendering helpers and environment
+func (t *Task) AsService() *Service {
+	return &Service{
+		Name:        t.Name,
+		Cmd:         t.Cmd,
+		CmdArgs:     t.CmdArgs,
+		Options:     t.Options,
+		HelperPath:  t.HelperPath,
+		Application: t.Application,
+	}
+}
+
+// GetUser returns name of user for running task
+func (t *Task) GetUser() string {
+	return t.AsService().GetUser()
+}
+
+// GetGroup returns name of group for running task
+func (t *Task) GetGroup() string {
+	return t.AsService().GetGroup()
+}
+
+// Validate validates task
+func (t *Task) Validate() *errors.Bundle {
+	var errs errors.Bundle
+
+	if !regexp.MustCompile(REGEXP_NAME_CHECK).MatchString(t.Name) {
+		errs.Add(&Error{Rule: RULE_INVALID_NAME, Message: fmt.Sprintf("Task name %s is misformatted and can't be accepted", t.Name)})
+	}
+
+	if t.Application != nil && t.Application.GetServiceByUnit(t.Name) != nil {
+		errs.Add(&Error{Rule: RULE_INVALID_NAME, Message: fmt.Sprintf("Task name %s is already used by command", t.Name)})
+	}
+
+	if t.Application != nil && t.Application.GetSchedule(t.Name) != nil {
+		errs.Add(&Error{Rule: RULE_INVALID_NAME, Message: fmt.Sprintf("Task name %s is already used by scheduled job", t.Name)})
+	}
+
+	if t.Cmd == "" {
+		errs.Add(&Error{Rule: RULE_INVALID_VALUE, Path: "command", Message: "task command can't be empty"})
+	}
+
+	errs.Add(t.Options.Validate())
+
+	t.annotate(errs.All()...)
+
+	return &errs
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// annotate adds path of task to errors and finds their position in procfile
+func (t *Task) annotate(errs ...error) {
+	for _, err := range errs {
+		e, ok := err.(*Error)
+
+		if !ok || !e.Pos.IsZero() || strings.HasPrefix(e.Path, "tasks.") {
+			continue
+		}
+
+		e.Path = strings.TrimSuffix("tasks."+t.Name+"."+e.Path, ".")
+	}
+
+	if t.Application != nil {
+		t.Application.source.annotate("", errs...)
+	}
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// parseV2Tasks parse tasks, tasks are kept in document order, because they are
+// executed one by one
+func parseV2Tasks(yaml *simpleyaml.Yaml, src *source) ([]*Task, error) {
+	if !yaml.IsExist("tasks") {
+		return nil, nil
+	}
+
+	names, err := yaml.Get("tasks").GetMapKeys()
+
+	if err != nil {
+		return nil, formatPropError("tasks", err)
+	}
+
+	commonOptions := &ServiceOptions{}
+	err = parseV2Options(commonOptions, yaml, "")
+
+	if err != nil {
+		return nil, err
+	}
+
+	var tasks []*Task
+
+	for _, name := range names {
+		taskYaml := yaml.GetPath("tasks", name)
+		prefix := "tasks." + name + "."
+
+		if !taskYaml.IsMap() {
+			return nil, &Error{Rule: RULE_INVALID_TYPE, Path: prefix[:len(prefix)-1], Message: "expected map"}
+		}
+
+		task := &Task{Name: name}
+		task.Cmd, task.CmdArgs, err = parseV2CommandValue(taskYaml, "command", prefix)
+
+		if err != nil {
+			return nil, err
+		}
+
+		options := &ServiceOptions{}
+		err = parseV2Options(options, taskYaml, prefix)
+
+		if err != nil {
+			return nil, err
+		}
+
+		task.Options = newJobOptions(options, commonOptions)
+		tasks = append(tasks, task)
+	}
+
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return src.orderOf("tasks."+tasks[i].Name) < src.orderOf("tasks."+tasks[j].Name)
+	})
+
+	return tasks, nil
+}
+
+// marshalTask encodes task to mapping node
+func marshalTask(t *Task, options *ServiceOptions, skipWorkingDir bool) *yaml.Node {
+	node := newMapNode()
+
+	addCommand(node, "command", t.Cmd, t.CmdArgs)
+
+	marshalOptions(node, options, skipWorkingDir)
+
+	return node
+}
@@ -0,0 +1,396 @@
+package procfile
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                           Copyright (c) 2006-2024 FUNBOX                           //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/essentialkaos/ek/v13/errors"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Built-in variables
+const (
+	VAR_APP_NAME     = "APP_NAME"
+	VAR_SERVICE_NAME = "SERVICE_NAME"
+	VAR_INSTANCE     = "INSTANCE"
+	VAR_PORT         = "PORT"
+	VAR_WORKING_DIR  = "WORKING_DIR"
+)
+
+// REGEXP_VAR_NAME is regexp for checking variable name
+const REGEXP_VAR_NAME = `\A[A-Za-z_][A-Za-z0-9_]*\z`
+
+// RULE_UNRESOLVED_VAR is rule ID for unresolved variables errors
+const RULE_UNRESOLVED_VAR = "unresolved-variable"
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// resolver resolves variables in procfile values
+type resolver struct {
+	vars     map[string]string
+	builtins map[string]string
+	deferred []string // Variables resolved on export
+	known    []string // Variables which are set in environment of process
+	isStrict bool     // Report unknown variables (procfile declares vars)
+	useEnv   bool
+	errs     errors.Bundle
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// WithInstance returns copy of service for instance with given index (empty
+// for services without count) with resolved INSTANCE and PORT variables and
+// environment variables
+func (s *Service) WithInstance(index string) *Service {
+	service := *s
+
+	if s.Options != nil {
+		options := *s.Options
+		service.Options = &options
+	}
+
+	port := s.InstancePort(index)
+
+	service.mapValues(func(value string) string {
+		value = strings.ReplaceAll(value, "${"+VAR_INSTANCE+"}", index)
+
+		if port != 0 {
+			value = strings.ReplaceAll(value, "${"+VAR_PORT+"}", strconv.Itoa(port))
+		}
+
+		return value
+	})
+
+	if service.Options == nil || (index == "" && port == 0) {
+		return &service
+	}
+
+	if service.Options.Env == nil {
+		service.Options.Env = make(map[string]string)
+	}
+
+	if index != "" && service.Options.Env[VAR_INSTANCE] == "" {
+		service.Options.Env[VAR_INSTANCE] = index
+	}
+
+	if port != 0 && service.Options.Env[VAR_PORT] == "" {
+		service.Options.Env[VAR_PORT] = strconv.Itoa(port)
+	}
+
+	return &service
+}
+
+// InstancePort returns port for instance with given index (first instance
+// uses base port)
+func (s *Service) InstancePort(index string) int {
+	if s.Port == 0 {
+		return 0
+	}
+
+	num, err := strconv.Atoi(index)
+
+	if err != nil || num < 1 {
+		return s.Port
+	}
+
+	return s.Port + num - 1
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// mapValues replaces all values which can contain variables using given function
+func (s *Service) mapValues(fn func(value string) string) {
+	s.Cmd, s.CmdArgs = mapCommand(s.Cmd, s.CmdArgs, fn)
+	s.PreCmd, s.PreCmdArgs = mapCommand(s.PreCmd, s.PreCmdArgs, fn)
+	s.PostCmd, s.PostCmdArgs = mapCommand(s.PostCmd, s.PostCmdArgs, fn)
+
+	if s.HealthCheck != nil {
+		check := *s.HealthCheck
+		check.mapValues(fn)
+		s.HealthCheck = &check
+	}
+
+	if s.Options == nil {
+		return
+	}
+
+	s.Options.WorkingDir = fn(s.Options.WorkingDir)
+	s.Options.LogFile = fn(s.Options.LogFile)
+	s.Options.PIDFile = fn(s.Options.PIDFile)
+
+	if s.Options.EnvFiles != nil {
+		files := make([]EnvFile, len(s.Options.EnvFiles))
+
+		for i, file := range s.Options.EnvFiles {
+			files[i] = EnvFile{Path: fn(file.Path), Required: file.Required}
+		}
+
+		s.Options.EnvFiles = files
+	}
+
+	if s.Options.Env != nil {
+		env := make(map[string]string, len(s.Options.Env))
+
+		for k, v := range s.Options.Env {
+			env[k] = fn(v)
+		}
+
+		s.Options.Env = env
+	}
+}
+
+// mapCommand replaces command or all its arguments (if command is defined as
+// list) using given function
+func mapCommand(cmd string, args []string, fn func(value string) string) (string, []string) {
+	if args == nil {
+		return fn(cmd), nil
+	}
+
+	result := make([]string, len(args))
+
+	for index, arg := range args {
+		result[index] = fn(arg)
+	}
+
+	return QuoteArgs(result), result
+}
+
+// interpolate resolves variables in all values of application
+func interpolate(app *Application, vars map[string]string, useEnv bool) errors.Errors {
+	r := &resolver{
+		vars:     vars,
+		isStrict: vars != nil,
+		useEnv:   useEnv,
+		builtins: map[string]string{VAR_APP_NAME: app.Name},
+		deferred: []string{VAR_INSTANCE},
+	}
+
+	app.WorkingDir = r.resolve(app.WorkingDir, "", "working_directory", "")
+
+	for _, service := range app.Services {
+		r.builtins[VAR_SERVICE_NAME] = service.Name
+		r.builtins[VAR_WORKING_DIR] = app.WorkingDir
+		r.deferred = []string{VAR_INSTANCE}
+
+		if service.Port != 0 {
+			r.deferred = append(r.deferred, VAR_PORT)
+		}
+
+		if service.Options == nil {
+			service.Options = &ServiceOptions{}
+		}
+
+		options := service.Options
+		r.setKnown(options)
+		options.WorkingDir = r.resolve(options.WorkingDir, service.Name, "working_directory", "")
+
+		if options.WorkingDir != "" {
+			r.builtins[VAR_WORKING_DIR] = options.WorkingDir
+		}
+
+		service.Cmd, service.CmdArgs = r.resolveCommand(service.Cmd, service.CmdArgs, service.Name, "command")
+		service.PreCmd, service.PreCmdArgs = r.resolveCommand(service.PreCmd, service.PreCmdArgs, service.Name, "pre")
+		service.PostCmd, service.PostCmdArgs = r.resolveCommand(service.PostCmd, service.PostCmdArgs, service.Name, "post")
+		options.LogFile = r.resolve(options.LogFile, service.Name, "log", "")
+		options.PIDFile = r.resolve(options.PIDFile, service.Name, "pid_file", "")
+
+		if service.HealthCheck != nil {
+			check := service.HealthCheck
+			check.Command = r.resolve(check.Command, service.Name, "healthcheck.command", "")
+			check.TCP = r.resolve(check.TCP, service.Name, "healthcheck.tcp", "")
+			check.HTTP = r.resolve(check.HTTP, service.Name, "healthcheck.http", "")
+		}
+
+		for i, file := range options.EnvFiles {
+			options.EnvFiles[i].Path = r.resolve(file.Path, service.Name, "env_file", "")
+		}
+
+		for _, name := range slices.Sorted(maps.Keys(options.Env)) {
+			options.Env[name] = r.resolve(options.Env[name], service.Name, "env."+name, name)
+		}
+	}
+
+	for _, schedule := range app.Schedules {
+		schedule.Cmd, schedule.CmdArgs = r.resolveJob(
+			app, schedule.Name, "schedules."+schedule.Name+".",
+			schedule.Cmd, schedule.CmdArgs, schedule.Options,
+		)
+	}
+
+	for _, task := range app.Tasks {
+		task.Cmd, task.CmdArgs = r.resolveJob(
+			app, task.Name, "tasks."+task.Name+".",
+			task.Cmd, task.CmdArgs, task.Options,
+		)
+	}
+
+	return r.errs.All()
+}
+
+// resolveJob resolves variables in options of scheduled job or task and returns
+// resolved command
+func (r *resolver) resolveJob(app *Application, name, prefix, cmd string, args []string, options *ServiceOptions) (string, []string) {
+	r.builtins[VAR_SERVICE_NAME] = name
+	r.builtins[VAR_WORKING_DIR] = app.WorkingDir
+	r.deferred = nil
+	r.setKnown(options)
+
+	options.WorkingDir = r.resolve(options.WorkingDir, "", prefix+"working_directory", "")
+
+	if options.WorkingDir != "" {
+		r.builtins[VAR_WORKING_DIR] = options.WorkingDir
+	}
+
+	cmd, args = r.resolveCommand(cmd, args, "", prefix+"command")
+	options.LogFile = r.resolve(options.LogFile, "", prefix+"log", "")
+
+	for i, file := range options.EnvFiles {
+		options.EnvFiles[i].Path = r.resolve(file.Path, "", prefix+"env_file", "")
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(options.Env)) {
+		options.Env[name] = r.resolve(options.Env[name], "", prefix+"env."+name, name)
+	}
+
+	return cmd, args
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// setKnown sets variables which are defined in environment of process, so
+// references to them are kept as is and expanded by shell
+func (r *resolver) setKnown(options *ServiceOptions) {
+	r.known = slices.Collect(maps.Keys(options.Env))
+
+	// Content of env files is unknown until start, so any variable can be
+	// defined there
+	if options.IsEnvFileSet() {
+		r.known = nil
+		r.isStrict = false
+	} else {
+		r.isStrict = r.vars != nil
+	}
+}
+
+// resolve resolves all variables in given value of property, references to
+// itself (for env variables) and to INSTANCE are kept as is
+func (r *resolver) resolve(value, service, path, self string) string {
+	result, errs := r.expand(value, self, nil)
+
+	for _, err := range errs {
+		r.errs.Add(&Error{Rule: RULE_UNRESOLVED_VAR, Service: service, Path: path, Message: err.Error()})
+	}
+
+	return result
+}
+
+// resolveCommand resolves all variables in command or its arguments
+func (r *resolver) resolveCommand(cmd string, args []string, service, path string) (string, []string) {
+	return mapCommand(cmd, args, func(value string) string {
+		return r.resolve(value, service, path, "")
+	})
+}
+
+// expand expands all variables in given value and returns errors for all
+// references which can't be resolved
+func (r *resolver) expand(value, self string, visited []string) (string, []error) {
+	var result strings.Builder
+	var errs []error
+
+	for {
+		index := strings.Index(value, "${")
+
+		if index == -1 {
+			result.WriteString(value)
+			break
+		}
+
+		// $${ is used for escaping
+		if index > 0 && value[index-1] == '$' {
+			result.WriteString(value[:index-1] + "${")
+			value = value[index+2:]
+			continue
+		}
+
+		end := strings.Index(value[index:], "}")
+
+		if end == -1 {
+			result.WriteString(value)
+			break
+		}
+
+		name := value[index+2 : index+end]
+		ref := value[index : index+end+1]
+
+		result.WriteString(value[:index])
+		value = value[index+end+1:]
+
+		resolved, lookupErrs := r.lookup(name, self, visited)
+
+		errs = append(errs, lookupErrs...)
+
+		if len(lookupErrs) != 0 || resolved == nil {
+			result.WriteString(ref)
+		} else {
+			result.WriteString(*resolved)
+		}
+	}
+
+	return result.String(), errs
+}
+
+// lookup returns value of variable with given name or nil if reference
+// must be kept as is
+func (r *resolver) lookup(name, self string, visited []string) (*string, []error) {
+	switch {
+	case !regexp.MustCompile(REGEXP_VAR_NAME).MatchString(name),
+		slices.Contains(r.deferred, name):
+		return nil, nil
+	case slices.Contains(visited, name):
+		return nil, []error{fmt.Errorf("variable %q has circular reference", name)}
+	}
+
+	value, ok := r.vars[name]
+
+	if ok {
+		result, errs := r.expand(value, "", append(visited, name))
+		return &result, errs
+	}
+
+	value, ok = r.builtins[name]
+
+	if ok {
+		return &value, nil
+	}
+
+	if name == self || slices.Contains(r.known, name) {
+		return nil, nil
+	}
+
+	if r.useEnv {
+		value, ok = os.LookupEnv(name)
+
+		if ok {
+			return &value, nil
+		}
+	}
+
+	// Variables which are not declared in procfile are expanded by shell
+	if !r.isStrict {
+		return nil, nil
+	}
+
+	return nil, []error{fmt.Errorf("unresolved variable %q", name)}
+}
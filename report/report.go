@@ -0,0 +1,314 @@
+package report
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                           Copyright (c) 2006-2024 FUNBOX                           //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/funbox/init-exporter/procfile"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Findings severities
+const (
+	SEVERITY_ERROR   = "error"
+	SEVERITY_WARNING = "warning"
+)
+
+// SARIF_SCHEMA contains URL of SARIF 2.1.0 JSON schema
+const SARIF_SCHEMA = "https://json.schemastore.org/sarif-2.1.0.json"
+
+// SARIF_SRCROOT contains ID of base URI for paths relative to working directory
+const SARIF_SRCROOT = "%SRCROOT%"
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Finding contains info about problem found in procfile
+type Finding struct {
+	Severity string `json:"severity"`
+	Rule     string `json:"rule"`
+	Message  string `json:"message"`
+	Service  string `json:"service,omitempty"`
+	Path     string `json:"path,omitempty"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+}
+
+// Tool contains info about tool which generates report
+type Tool struct {
+	Name    string
+	Version string
+	URL     string
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+type sarifLog struct {
+	Schema  string      `json:"$schema"`
+	Version string      `json:"version"`
+	Runs    []*sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool               *sarifTool                        `json:"tool"`
+	OriginalURIBaseIDs map[string]*sarifArtifactLocation `json:"originalUriBaseIds,omitempty"`
+	Results            []*sarifResult                    `json:"results"`
+}
+
+type sarifTool struct {
+	Driver *sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string       `json:"name"`
+	Version        string       `json:"version,omitempty"`
+	InformationURI string       `json:"informationUri,omitempty"`
+	Rules          []*sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string        `json:"id"`
+	ShortDescription *sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID    string           `json:"ruleId"`
+	Level     string           `json:"level"`
+	Message   *sarifMessage    `json:"message"`
+	Locations []*sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation *sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion           `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// rulesDesc contains short descriptions of rules
+var rulesDesc = map[string]string{
+	procfile.RULE_SYNTAX:           "Procfile syntax error",
+	procfile.RULE_INVALID_TYPE:     "Property value has wrong type",
+	procfile.RULE_INVALID_VALUE:    "Property value is not valid",
+	procfile.RULE_INVALID_NAME:     "Name is misformatted",
+	procfile.RULE_INVALID_ENV:      "Environment variable is not valid",
+	procfile.RULE_INSECURE_PATH:    "Path is insecure",
+	procfile.RULE_UNKNOWN_PROPERTY: "Property is unknown",
+	procfile.RULE_UNSUPPORTED:      "Feature is not supported",
+	procfile.RULE_UNRESOLVED_VAR:   "Variable can't be resolved",
+	procfile.RULE_INVALID_INCLUDE:  "Included file can't be loaded",
+
+	procfile.LINT_NO_KILL_TIMEOUT:       "Service without respawn has no kill timeout",
+	procfile.LINT_LOW_NOFILE:            "Descriptors limit is too low",
+	procfile.LINT_MEMORY_HIGH_ABOVE_MAX: "Memory throttling limit is greater than hard limit",
+	procfile.LINT_COUNT_ABOVE_CPUS:      "Number of instances is greater than number of CPUs",
+	procfile.LINT_KILL_MODE_NONE:        "Processes are not killed on stop",
+	procfile.LINT_UPSTART_RESOURCES:     "Resources limits are not supported by upstart",
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// FromErrors converts procfile errors to findings
+func FromErrors(errs []error) []*Finding {
+	var result []*Finding
+
+	for _, err := range errs {
+		result = append(result, FromError(err))
+	}
+
+	return result
+}
+
+// FromError converts procfile error to finding
+func FromError(err error) *Finding {
+	e, ok := err.(*procfile.Error)
+
+	if !ok {
+		return &Finding{
+			Severity: SEVERITY_ERROR,
+			Rule:     procfile.RULE_SYNTAX,
+			Message:  err.Error(),
+		}
+	}
+
+	return &Finding{
+		Severity: SEVERITY_ERROR,
+		Rule:     e.Rule,
+		Message:  e.Message,
+		Service:  e.Service,
+		Path:     e.Path,
+		File:     e.Pos.File,
+		Line:     e.Pos.Line,
+		Column:   e.Pos.Column,
+	}
+}
+
+// FromWarnings converts procfile lint warnings to findings
+func FromWarnings(warnings []*procfile.Warning) []*Finding {
+	var result []*Finding
+
+	for _, w := range warnings {
+		result = append(result, &Finding{
+			Severity: w.Severity,
+			Rule:     w.Rule,
+			Message:  w.Message,
+			Service:  w.Service,
+			Path:     w.Path,
+			File:     w.Pos.File,
+			Line:     w.Pos.Line,
+			Column:   w.Pos.Column,
+		})
+	}
+
+	return result
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// JSON encodes findings as JSON
+func JSON(findings []*Finding) ([]byte, error) {
+	if findings == nil {
+		findings = []*Finding{}
+	}
+
+	return json.MarshalIndent(findings, "", "  ")
+}
+
+// SARIF encodes findings as SARIF 2.1.0 log
+func SARIF(findings []*Finding, tool Tool) ([]byte, error) {
+	run := &sarifRun{
+		Tool: &sarifTool{
+			Driver: &sarifDriver{
+				Name:           tool.Name,
+				Version:        tool.Version,
+				InformationURI: tool.URL,
+				Rules:          []*sarifRule{},
+			},
+		},
+		Results: []*sarifResult{},
+	}
+
+	var rules []string
+
+	workingDir, _ := os.Getwd()
+
+	for _, f := range findings {
+		if !slices.Contains(rules, f.Rule) {
+			rules = append(rules, f.Rule)
+		}
+
+		result := &sarifResult{
+			RuleID:  f.Rule,
+			Level:   f.Severity,
+			Message: &sarifMessage{formatMessage(f)},
+		}
+
+		if f.File != "" {
+			location := &sarifPhysicalLocation{
+				ArtifactLocation: getArtifactLocation(f.File, workingDir),
+			}
+
+			if location.ArtifactLocation.URIBaseID != "" {
+				run.OriginalURIBaseIDs = map[string]*sarifArtifactLocation{
+					SARIF_SRCROOT: {URI: fileURI(workingDir) + "/"},
+				}
+			}
+
+			if f.Line > 0 {
+				location.Region = &sarifRegion{StartLine: f.Line, StartColumn: f.Column}
+			}
+
+			result.Locations = []*sarifLocation{{location}}
+		}
+
+		run.Results = append(run.Results, result)
+	}
+
+	slices.Sort(rules)
+
+	for _, rule := range rules {
+		desc := rulesDesc[rule]
+
+		if desc == "" {
+			desc = rule
+		}
+
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, &sarifRule{
+			ID: rule, ShortDescription: &sarifMessage{desc},
+		})
+	}
+
+	return json.MarshalIndent(&sarifLog{
+		Schema:  SARIF_SCHEMA,
+		Version: "2.1.0",
+		Runs:    []*sarifRun{run},
+	}, "", "  ")
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// formatMessage formats finding message with path to property and service name
+func formatMessage(f *Finding) string {
+	if f.Path == "" {
+		return f.Message
+	}
+
+	return f.Path + ": " + f.Message
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// getArtifactLocation returns location of file relative to working directory or
+// file URI if file is outside of working directory
+func getArtifactLocation(file, workingDir string) *sarifArtifactLocation {
+	absPath, err := filepath.Abs(file)
+
+	if err != nil || workingDir == "" {
+		return &sarifArtifactLocation{URI: filepath.ToSlash(file)}
+	}
+
+	relPath, err := filepath.Rel(workingDir, absPath)
+
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, "../") {
+		return &sarifArtifactLocation{URI: fileURI(absPath)}
+	}
+
+	return &sarifArtifactLocation{
+		URI:       (&url.URL{Path: filepath.ToSlash(relPath)}).EscapedPath(),
+		URIBaseID: SARIF_SRCROOT,
+	}
+}
+
+// fileURI returns file URI for given absolute path
+func fileURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
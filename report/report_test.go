@@ -0,0 +1,151 @@
+package report
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                           Copyright (c) 2006-2024 FUNBOX                           //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/funbox/init-exporter/procfile"
+
+	. "github.com/essentialkaos/check"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func Test(t *testing.T) { TestingT(t) }
+
+type ReportSuite struct{}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+var _ = Suite(&ReportSuite{})
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func (s *ReportSuite) TestFindings(c *C) {
+	findings := FromErrors([]error{
+		&procfile.Error{
+			Pos:     procfile.Position{File: "Procfile", Line: 23, Column: 7},
+			Rule:    procfile.RULE_INVALID_TYPE,
+			Service: "web",
+			Path:    "commands.web.limits.nofile",
+			Message: "expected integer",
+		},
+		errors.New("Commands missing in Procfile"),
+	})
+
+	c.Assert(findings, HasLen, 2)
+	c.Assert(findings[0], DeepEquals, &Finding{
+		Severity: SEVERITY_ERROR,
+		Rule:     procfile.RULE_INVALID_TYPE,
+		Message:  "expected integer",
+		Service:  "web",
+		Path:     "commands.web.limits.nofile",
+		File:     "Procfile",
+		Line:     23,
+		Column:   7,
+	})
+	c.Assert(findings[1].Rule, Equals, procfile.RULE_SYNTAX)
+	c.Assert(findings[1].Message, Equals, "Commands missing in Procfile")
+}
+
+func (s *ReportSuite) TestWarnings(c *C) {
+	findings := FromWarnings([]*procfile.Warning{
+		{
+			Pos:      procfile.Position{File: "Procfile", Line: 11, Column: 7},
+			Severity: procfile.SEVERITY_WARNING,
+			Rule:     procfile.LINT_LOW_NOFILE,
+			Service:  "web",
+			Path:     "commands.web.limits.nofile",
+			Message:  "descriptors limit 512 is less than 1024",
+		},
+	})
+
+	c.Assert(findings, HasLen, 1)
+	c.Assert(findings[0].Severity, Equals, SEVERITY_WARNING)
+	c.Assert(findings[0].Rule, Equals, procfile.LINT_LOW_NOFILE)
+	c.Assert(findings[0].Line, Equals, 11)
+
+	data, err := SARIF(findings, Tool{Name: "init-exporter"})
+
+	c.Assert(err, IsNil)
+
+	log := &sarifLog{}
+
+	c.Assert(json.Unmarshal(data, log), IsNil)
+	c.Assert(log.Runs[0].Results[0].Level, Equals, "warning")
+	c.Assert(log.Runs[0].Tool.Driver.Rules[0].ShortDescription.Text, Equals, "Descriptors limit is too low")
+}
+
+func (s *ReportSuite) TestJSON(c *C) {
+	data, err := JSON(nil)
+
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "[]")
+
+	data, err = JSON([]*Finding{{Severity: SEVERITY_ERROR, Rule: "syntax", Message: "test", Line: 1}})
+
+	c.Assert(err, IsNil)
+
+	var findings []*Finding
+
+	c.Assert(json.Unmarshal(data, &findings), IsNil)
+	c.Assert(findings, HasLen, 1)
+	c.Assert(findings[0].Line, Equals, 1)
+}
+
+func (s *ReportSuite) TestSARIF(c *C) {
+	data, err := SARIF([]*Finding{
+		{
+			Severity: SEVERITY_ERROR,
+			Rule:     procfile.RULE_INVALID_TYPE,
+			Message:  "expected integer",
+			Service:  "web",
+			Path:     "commands.web.limits.nofile",
+			File:     "./Procfile",
+			Line:     23,
+			Column:   7,
+		},
+		{Severity: SEVERITY_ERROR, Rule: procfile.RULE_SYNTAX, Message: "Commands missing in Procfile"},
+	}, Tool{Name: "init-exporter", Version: "1.0.0"})
+
+	c.Assert(err, IsNil)
+
+	log := &sarifLog{}
+
+	c.Assert(json.Unmarshal(data, log), IsNil)
+	c.Assert(log.Version, Equals, "2.1.0")
+	c.Assert(log.Runs, HasLen, 1)
+	c.Assert(log.Runs[0].Tool.Driver.Name, Equals, "init-exporter")
+	c.Assert(log.Runs[0].Tool.Driver.Rules, HasLen, 2)
+	c.Assert(log.Runs[0].Tool.Driver.Rules[0].ID, Equals, procfile.RULE_INVALID_TYPE)
+	c.Assert(log.Runs[0].Results, HasLen, 2)
+
+	result := log.Runs[0].Results[0]
+
+	c.Assert(result.RuleID, Equals, procfile.RULE_INVALID_TYPE)
+	c.Assert(result.Level, Equals, "error")
+	c.Assert(result.Message.Text, Equals, "commands.web.limits.nofile: expected integer")
+	c.Assert(result.Locations, HasLen, 1)
+	c.Assert(result.Locations[0].PhysicalLocation.ArtifactLocation.URI, Equals, "Procfile")
+	c.Assert(result.Locations[0].PhysicalLocation.ArtifactLocation.URIBaseID, Equals, SARIF_SRCROOT)
+	c.Assert(result.Locations[0].PhysicalLocation.Region.StartLine, Equals, 23)
+	c.Assert(result.Locations[0].PhysicalLocation.Region.StartColumn, Equals, 7)
+
+	c.Assert(log.Runs[0].Results[1].Locations, IsNil)
+
+	workingDir, _ := os.Getwd()
+
+	c.Assert(log.Runs[0].OriginalURIBaseIDs[SARIF_SRCROOT].URI, Equals, "file://"+workingDir+"/")
+
+	c.Assert(getArtifactLocation("/srv/app/conf/my Procfile", "/srv/app"), DeepEquals, &sarifArtifactLocation{URI: "conf/my%20Procfile", URIBaseID: SARIF_SRCROOT})
+	c.Assert(getArtifactLocation("/etc/app/Procfile", "/srv/app"), DeepEquals, &sarifArtifactLocation{URI: "file:///etc/app/Procfile"})
+	c.Assert(getArtifactLocation("/srv/app/Procfile", "/srv/app"), DeepEquals, &sarifArtifactLocation{URI: "Procfile", URIBaseID: SARIF_SRCROOT})
+}